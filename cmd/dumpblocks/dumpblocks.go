@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"net/url"
 	"os"
 	"strconv"
@@ -13,7 +14,7 @@ import (
 
 	_ "embed"
 
-	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	ethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/maticnetwork/polygon-cli/proto/gen/pb"
 	"github.com/maticnetwork/polygon-cli/rpctypes"
 	"github.com/maticnetwork/polygon-cli/util"
@@ -37,11 +38,25 @@ type (
 		Mode               string
 		FilterStr          string
 		filter             Filter
+		FilterTo           []string
+		FilterMinValue     string
+		FilterType         int
+		txFilter           TxPredicate
+		Redact             []string
+		redactor           *rpctypes.Redactor
+		Diagnostics        bool
+		RPS                float64
+		Signatures         string
+		resolver           *rpctypes.SignatureResolver
 	}
 	Filter struct {
 		To   []string `json:"to"`
 		From []string `json:"from"`
 	}
+
+	// TxPredicate reports whether a transaction should be kept in the dumped
+	// block.
+	TxPredicate func(tx rpctypes.PolyTransaction) bool
 )
 
 var (
@@ -57,7 +72,7 @@ var DumpblocksCmd = &cobra.Command{
 	Long:  usage,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := cmd.Context()
-		ec, err := ethrpc.DialContext(ctx, args[0])
+		ec, err := util.DialRateLimited(ctx, args[0], inputDumpblocks.RPS)
 		if err != nil {
 			return err
 		}
@@ -94,7 +109,12 @@ var DumpblocksCmd = &cobra.Command{
 						continue
 					}
 
+					if inputDumpblocks.Diagnostics {
+						diagnoseBlocks(blocks)
+					}
+
 					blocks = filterBlocks(blocks)
+					blocks = filterTransactions(blocks, inputDumpblocks.txFilter)
 
 					if inputDumpblocks.ShouldDumpBlocks {
 						err = writeResponses(blocks, "block")
@@ -182,6 +202,26 @@ var DumpblocksCmd = &cobra.Command{
 			inputDumpblocks.filter.From[i] = strings.ToLower(inputDumpblocks.filter.From[i])
 		}
 
+		inputDumpblocks.txFilter, err = newTxPredicate(inputDumpblocks.FilterTo, inputDumpblocks.FilterMinValue, inputDumpblocks.FilterType)
+		if err != nil {
+			return err
+		}
+
+		if len(inputDumpblocks.Redact) > 0 {
+			if inputDumpblocks.redactor, err = rpctypes.NewRedactor(inputDumpblocks.Redact); err != nil {
+				return err
+			}
+		}
+
+		if inputDumpblocks.Signatures != "" {
+			if inputDumpblocks.Mode != "json" {
+				return fmt.Errorf("--signatures is only supported with the json output format")
+			}
+			if inputDumpblocks.resolver, err = rpctypes.LoadSignatureResolver(inputDumpblocks.Signatures); err != nil {
+				return err
+			}
+		}
+
 		return nil
 	},
 }
@@ -194,12 +234,71 @@ func init() {
 	DumpblocksCmd.PersistentFlags().StringVarP(&inputDumpblocks.Mode, "mode", "m", "json", "the output format [json, proto]")
 	DumpblocksCmd.PersistentFlags().Uint64VarP(&inputDumpblocks.BatchSize, "batch-size", "b", 150, "the batch size. Realistically, this probably shouldn't be bigger than 999. Most providers seem to cap at 1000.")
 	DumpblocksCmd.PersistentFlags().StringVarP(&inputDumpblocks.FilterStr, "filter", "F", "{}", "filter output based on tx to and from, not setting a filter means all are allowed")
+	DumpblocksCmd.PersistentFlags().StringSliceVar(&inputDumpblocks.FilterTo, "filter-to", nil, "only dump transactions sent to one of these addresses")
+	DumpblocksCmd.PersistentFlags().StringVar(&inputDumpblocks.FilterMinValue, "filter-min-value", "", "only dump transactions with at least this value in wei")
+	DumpblocksCmd.PersistentFlags().IntVar(&inputDumpblocks.FilterType, "filter-type", -1, "only dump transactions of this EIP-2718 type (-1 for any)")
+	DumpblocksCmd.PersistentFlags().BoolVar(&inputDumpblocks.Diagnostics, "diagnostics", false, "decode every field of the blocks and log the ones that are malformed")
+	DumpblocksCmd.PersistentFlags().Float64Var(&inputDumpblocks.RPS, "rps", 0, "the maximum number of requests per second to send across all go routines, 0 for unlimited")
+	DumpblocksCmd.PersistentFlags().StringVar(&inputDumpblocks.Signatures, "signatures", "", "a JSON file mapping 4 byte selectors to function signatures, used to add the called function's signatures to each transaction")
+	DumpblocksCmd.PersistentFlags().StringSliceVar(&inputDumpblocks.Redact, "redact", nil, "fields (e.g. input) or addresses to replace with a placeholder in the output, hashes are always kept")
+}
+
+// newTxPredicate combines the transaction filter flags into a single predicate
+// where every set filter must match. It returns nil if no filters are set.
+func newTxPredicate(to []string, minValue string, txType int) (TxPredicate, error) {
+	predicates := []TxPredicate{}
+
+	if len(to) > 0 {
+		addresses := make(map[ethcommon.Address]struct{}, len(to))
+		for _, addr := range to {
+			if !ethcommon.IsHexAddress(addr) {
+				return nil, fmt.Errorf("invalid --filter-to address: %s", addr)
+			}
+			addresses[ethcommon.HexToAddress(addr)] = struct{}{}
+		}
+		predicates = append(predicates, func(tx rpctypes.PolyTransaction) bool {
+			_, ok := addresses[tx.To()]
+			return ok
+		})
+	}
+
+	if minValue != "" {
+		min, ok := new(big.Int).SetString(minValue, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid --filter-min-value: %s", minValue)
+		}
+		predicates = append(predicates, func(tx rpctypes.PolyTransaction) bool {
+			return tx.Value().Cmp(min) >= 0
+		})
+	}
+
+	if txType >= 0 {
+		predicates = append(predicates, func(tx rpctypes.PolyTransaction) bool {
+			return tx.Type() == uint64(txType)
+		})
+	}
+
+	if len(predicates) == 0 {
+		return nil, nil
+	}
+
+	return func(tx rpctypes.PolyTransaction) bool {
+		for _, p := range predicates {
+			if !p(tx) {
+				return false
+			}
+		}
+		return true
+	}, nil
 }
 
 // writeResponses writes the data to either stdout or a file if one is provided.
 // The message type can be either "block" or "transaction". The format of the
 // output is either "json" or "proto" depending on the mode.
 func writeResponses(msg []*json.RawMessage, msgType string) error {
+	msg = annotateResponses(msg, msgType)
+	msg = redactResponses(msg, msgType)
+
 	switch inputDumpblocks.Mode {
 	case "json":
 		if err := writeJSON(msg); err != nil {
@@ -237,6 +336,54 @@ func writeResponses(msg []*json.RawMessage, msgType string) error {
 	return nil
 }
 
+// annotateResponses adds the resolved function signatures to the transactions
+// in the blocks. Blocks that can't be annotated are written as they are.
+func annotateResponses(msg []*json.RawMessage, msgType string) []*json.RawMessage {
+	if inputDumpblocks.resolver == nil || msgType != "block" {
+		return msg
+	}
+
+	annotated := make([]*json.RawMessage, 0, len(msg))
+	for _, m := range msg {
+		out, err := inputDumpblocks.resolver.AnnotateBlock(*m)
+		if err != nil {
+			log.Error().Err(err).Msg("Unable to annotate block")
+			annotated = append(annotated, m)
+			continue
+		}
+		annotated = append(annotated, &out)
+	}
+	return annotated
+}
+
+// redactResponses redacts the configured fields and addresses from the blocks
+// or receipts. Responses that can't be redacted are dropped rather than
+// written unredacted.
+func redactResponses(msg []*json.RawMessage, msgType string) []*json.RawMessage {
+	if inputDumpblocks.redactor == nil {
+		return msg
+	}
+
+	redacted := make([]*json.RawMessage, 0, len(msg))
+	for _, m := range msg {
+		var (
+			out json.RawMessage
+			err error
+		)
+		if msgType == "block" {
+			out, err = inputDumpblocks.redactor.RedactBlock(*m)
+		} else {
+			out, err = inputDumpblocks.redactor.RedactObject(*m)
+		}
+		if err != nil {
+			log.Error().Err(err).Msgf("Unable to redact %s, skipping it", msgType)
+			continue
+		}
+		redacted = append(redacted, &out)
+	}
+	return redacted
+}
+
 // writeJSON writes the json raw messages to stdout by default and to a file if
 // provided.
 func writeJSON(msg []*json.RawMessage) error {
@@ -288,6 +435,33 @@ func writeProto(out []byte) error {
 	return nil
 }
 
+// diagnoseBlocks logs a warning for every block with malformed fields. The
+// blocks are still dumped as is, so the warnings flag which parts of the dump
+// are partially corrupt.
+func diagnoseBlocks(blocks []*json.RawMessage) {
+	for _, msg := range blocks {
+		var block rpctypes.RawBlockResponse
+		if err := json.Unmarshal(*msg, &block); err != nil {
+			log.Warn().Err(err).Msg("Unable to unmarshal block for diagnostics")
+			continue
+		}
+
+		pb, diags := block.DecodeWithDiagnostics()
+		if len(diags) == 0 {
+			continue
+		}
+		fields := make(map[string]string, len(diags))
+		for field, err := range diags {
+			fields[field] = err.Error()
+		}
+		log.Warn().
+			Str("number", string(block.Number)).
+			Str("hash", pb.Hash().Hex()).
+			Interface("fields", fields).
+			Msg("Block has malformed fields")
+	}
+}
+
 // filterBlocks will filter blocks that having transactions with a matching to or
 // from field. If the to or from is an empty slice, then it will match all.
 func filterBlocks(blocks []*json.RawMessage) []*json.RawMessage {
@@ -314,3 +488,57 @@ func filterBlocks(blocks []*json.RawMessage) []*json.RawMessage {
 
 	return filtered
 }
+
+// filterTransactions removes the transactions that don't match the predicate
+// from each block while keeping the rest of the block untouched. Blocks are
+// still written even if none of their transactions match so the block metadata
+// is recorded.
+func filterTransactions(blocks []*json.RawMessage, match TxPredicate) []*json.RawMessage {
+	if match == nil {
+		return blocks
+	}
+
+	filtered := make([]*json.RawMessage, 0, len(blocks))
+	for _, msg := range blocks {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(*msg, &fields); err != nil {
+			log.Error().Bytes("block", *msg).Msg("Unable to unmarshal block")
+			continue
+		}
+
+		var txs []json.RawMessage
+		if err := json.Unmarshal(fields["transactions"], &txs); err != nil {
+			log.Error().Bytes("block", *msg).Msg("Unable to unmarshal block transactions")
+			continue
+		}
+
+		kept := make([]json.RawMessage, 0, len(txs))
+		for _, rawTx := range txs {
+			var tx rpctypes.RawTransactionResponse
+			if err := json.Unmarshal(rawTx, &tx); err != nil {
+				log.Error().Bytes("tx", rawTx).Msg("Unable to unmarshal transaction")
+				continue
+			}
+			if match(rpctypes.NewPolyTransaction(&tx)) {
+				kept = append(kept, rawTx)
+			}
+		}
+
+		var err error
+		if fields["transactions"], err = json.Marshal(kept); err != nil {
+			log.Error().Err(err).Msg("Unable to marshal filtered transactions")
+			continue
+		}
+
+		out, err := json.Marshal(fields)
+		if err != nil {
+			log.Error().Err(err).Msg("Unable to marshal filtered block")
+			continue
+		}
+
+		raw := json.RawMessage(out)
+		filtered = append(filtered, &raw)
+	}
+
+	return filtered
+}
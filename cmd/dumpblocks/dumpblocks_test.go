@@ -0,0 +1,146 @@
+package dumpblocks
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/maticnetwork/polygon-cli/rpctypes"
+)
+
+const (
+	testRecipient = "0x00000000000000000000000000000000000000aa"
+	testOther     = "0x00000000000000000000000000000000000000bb"
+)
+
+func TestNewTxPredicate(t *testing.T) {
+	type test struct {
+		name     string
+		to       []string
+		minValue string
+		txType   int
+		tx       rpctypes.RawTransactionResponse
+		nilPred  bool
+		matches  bool
+		fail     bool
+	}
+
+	tests := []test{
+		{name: "no filters", txType: -1, nilPred: true},
+		{name: "to matches", to: []string{testOther, testRecipient}, txType: -1,
+			tx: rpctypes.RawTransactionResponse{To: testRecipient}, matches: true},
+		{name: "to matches any casing", to: []string{"0x00000000000000000000000000000000000000AA"}, txType: -1,
+			tx: rpctypes.RawTransactionResponse{To: testRecipient}, matches: true},
+		{name: "to differs", to: []string{testOther}, txType: -1,
+			tx: rpctypes.RawTransactionResponse{To: testRecipient}},
+		{name: "contract creation", to: []string{testRecipient}, txType: -1},
+		{name: "min value equal", minValue: "1000", txType: -1,
+			tx: rpctypes.RawTransactionResponse{Value: "0x3e8"}, matches: true},
+		{name: "min value below", minValue: "1001", txType: -1,
+			tx: rpctypes.RawTransactionResponse{Value: "0x3e8"}},
+		{name: "type matches", txType: 2,
+			tx: rpctypes.RawTransactionResponse{Type: "0x2"}, matches: true},
+		{name: "type differs", txType: 2,
+			tx: rpctypes.RawTransactionResponse{Type: "0x0"}},
+		{name: "every filter matches", to: []string{testRecipient}, minValue: "1", txType: 2,
+			tx: rpctypes.RawTransactionResponse{To: testRecipient, Value: "0x1", Type: "0x2"}, matches: true},
+		{name: "one filter differs", to: []string{testRecipient}, minValue: "2", txType: 2,
+			tx: rpctypes.RawTransactionResponse{To: testRecipient, Value: "0x1", Type: "0x2"}},
+		{name: "invalid to", to: []string{"0xaa"}, txType: -1, fail: true},
+		{name: "invalid min value", minValue: "1e18", txType: -1, fail: true},
+	}
+
+	for _, tc := range tests {
+		match, err := newTxPredicate(tc.to, tc.minValue, tc.txType)
+		if tc.fail {
+			if err == nil {
+				t.Errorf("%s: expected an error", tc.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: could not build predicate: %v", tc.name, err)
+			continue
+		}
+		if tc.nilPred {
+			if match != nil {
+				t.Errorf("%s: expected no predicate", tc.name)
+			}
+			continue
+		}
+		if got := match(rpctypes.NewPolyTransaction(&tc.tx)); got != tc.matches {
+			t.Errorf("%s: expected match %t, got %t", tc.name, tc.matches, got)
+		}
+	}
+}
+
+func TestFilterTransactions(t *testing.T) {
+	match, err := newTxPredicate([]string{testRecipient}, "", -1)
+	if err != nil {
+		t.Fatalf("could not build predicate: %v", err)
+	}
+
+	newBlock := func(s string) *json.RawMessage {
+		raw := json.RawMessage(s)
+		return &raw
+	}
+
+	type test struct {
+		name    string
+		block   string
+		hashes  []string
+		dropped bool
+	}
+
+	tests := []test{
+		{name: "mixed", block: `{"number":"0x1","transactions":[{"hash":"0x01","to":"` + testRecipient + `"},{"hash":"0x02","to":"` + testOther + `"},{"hash":"0x03","to":"` + testRecipient + `"}]}`,
+			hashes: []string{"0x01", "0x03"}},
+		{name: "none match", block: `{"number":"0x2","transactions":[{"hash":"0x04","to":"` + testOther + `"}]}`,
+			hashes: []string{}},
+		{name: "empty block", block: `{"number":"0x3","transactions":[]}`, hashes: []string{}},
+		{name: "malformed block", block: `[]`, dropped: true},
+		{name: "malformed transactions", block: `{"number":"0x4","transactions":{}}`, dropped: true},
+	}
+
+	for _, tc := range tests {
+		filtered := filterTransactions([]*json.RawMessage{newBlock(tc.block)}, match)
+		if tc.dropped {
+			if len(filtered) != 0 {
+				t.Errorf("%s: expected the block to be dropped, got %s", tc.name, *filtered[0])
+			}
+			continue
+		}
+		if len(filtered) != 1 {
+			t.Errorf("%s: expected 1 block, got %d", tc.name, len(filtered))
+			continue
+		}
+
+		var block struct {
+			Number       string `json:"number"`
+			Transactions []struct {
+				Hash string `json:"hash"`
+			} `json:"transactions"`
+		}
+		if err = json.Unmarshal(*filtered[0], &block); err != nil {
+			t.Errorf("%s: could not decode %s: %v", tc.name, *filtered[0], err)
+			continue
+		}
+		if block.Number == "" {
+			t.Errorf("%s: expected the block fields to be kept, got %s", tc.name, *filtered[0])
+		}
+		if len(block.Transactions) != len(tc.hashes) {
+			t.Errorf("%s: expected %d transactions, got %s", tc.name, len(tc.hashes), *filtered[0])
+			continue
+		}
+		for idx, hash := range tc.hashes {
+			if block.Transactions[idx].Hash != hash {
+				t.Errorf("%s: expected transaction %d to be %s, got %s", tc.name, idx, hash, block.Transactions[idx].Hash)
+			}
+		}
+	}
+
+	// Without a predicate the blocks are returned as they are.
+	blocks := []*json.RawMessage{newBlock(`{"transactions":[{"hash":"0x05"}]}`)}
+	if filtered := filterTransactions(blocks, nil); len(filtered) != 1 || filtered[0] != blocks[0] {
+		t.Errorf("expected the blocks to be unchanged without a predicate")
+	}
+}
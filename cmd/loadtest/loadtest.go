@@ -1158,8 +1158,8 @@ func loadTestRecall(ctx context.Context, c *ethclient.Client, nonce uint64, orig
 			_, err = c.CallContract(ctx, callMsg, nil)
 		} else {
 			callMsg.GasPrice = originalTx.GasPrice()
-			callMsg.GasFeeCap = new(big.Int).SetUint64(originalTx.MaxFeePerGas())
-			callMsg.GasTipCap = new(big.Int).SetUint64(originalTx.MaxPriorityFeePerGas())
+			callMsg.GasFeeCap = originalTx.MaxFeePerGas()
+			callMsg.GasTipCap = originalTx.MaxPriorityFeePerGas()
 			_, err = c.CallContract(ctx, callMsg, originalTx.BlockNumber())
 		}
 		if err != nil {
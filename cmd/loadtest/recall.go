@@ -127,7 +127,7 @@ func deduplicate(slice []string) []string {
 }
 
 func rawTransactionToNewTx(pt rpctypes.PolyTransaction, nonce uint64, price, tipCap *big.Int) *ethtypes.Transaction {
-	if pt.MaxFeePerGas() != 0 || pt.ChainID() != 0 {
+	if chainID := pt.ChainID(); pt.Type() == ethtypes.DynamicFeeTxType || (chainID != nil && chainID.Sign() != 0) {
 		return rawTransactionToDynamicFeeTx(pt, nonce, price, tipCap)
 	}
 	return rawTransactionToLegacyTx(pt, nonce, price)
@@ -135,7 +135,10 @@ func rawTransactionToNewTx(pt rpctypes.PolyTransaction, nonce uint64, price, tip
 
 func rawTransactionToDynamicFeeTx(pt rpctypes.PolyTransaction, nonce uint64, price, tipCap *big.Int) *ethtypes.Transaction {
 	toAddr := pt.To()
-	chainId := new(big.Int).SetUint64(pt.ChainID())
+	chainId := pt.ChainID()
+	if chainId == nil {
+		chainId = new(big.Int)
+	}
 	dynamicFeeTx := &ethtypes.DynamicFeeTx{
 		ChainID:   chainId,
 		To:        &toAddr,
@@ -20,6 +20,7 @@ var (
 	batchSizeValue  string
 	blockCacheLimit int
 	intervalStr     string
+	reorgWarnDepth  uint64
 
 	defaultBatchSize = 100
 )
@@ -79,6 +80,7 @@ func init() {
 	MonitorCmd.PersistentFlags().StringVarP(&batchSizeValue, "batch-size", "b", "auto", "Number of requests per batch")
 	MonitorCmd.PersistentFlags().IntVarP(&blockCacheLimit, "cache-limit", "c", 200, "Number of cached blocks for the LRU block data structure (Min 100)")
 	MonitorCmd.PersistentFlags().StringVarP(&intervalStr, "interval", "i", "5s", "Amount of time between batch block rpc calls")
+	MonitorCmd.PersistentFlags().Uint64Var(&reorgWarnDepth, "reorg-warn-depth", 0, "Log a warning for reorgs deeper than this many blocks (0 to disable)")
 }
 
 func checkFlags() (err error) {
@@ -13,6 +13,7 @@ import (
 
 	_ "embed"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/ethclient"
 	ethrpc "github.com/ethereum/go-ethereum/rpc"
 
@@ -55,6 +56,14 @@ var (
 
 	// size of the sub batches to divide and conquer the total batch size with
 	subBatchSize = 50
+
+	// tpsWindow is the wall-clock window used to compute the transactions per second.
+	tpsWindow = 30 * time.Second
+
+	// maxNewHeads bounds how many blocks that arrived since the last poll are
+	// counted by the TPS meter, so catching up after a stall doesn't count a
+	// burst of old blocks as arriving now.
+	maxNewHeads uint64 = 16
 )
 
 type (
@@ -69,8 +78,10 @@ type (
 		PendingCount        uint64
 		SelectedBlock       rpctypes.PolyBlock
 		SelectedTransaction rpctypes.PolyTransaction
-		BlockCache          *lru.Cache   `json:"-"`
-		BlocksLock          sync.RWMutex `json:"-"`
+		TxMeter             *metrics.TPSMeter     `json:"-"`
+		ReorgTracker        *metrics.ReorgTracker `json:"reorgs"`
+		BlockCache          *lru.Cache            `json:"-"`
+		BlocksLock          sync.RWMutex          `json:"-"`
 	}
 	chainState struct {
 		HeadBlock    uint64
@@ -122,6 +133,8 @@ func monitor(ctx context.Context) error {
 
 	ms.ChainID = big.NewInt(0)
 	ms.PendingCount = 0
+	ms.TxMeter = metrics.NewTPSMeter(tpsWindow)
+	ms.ReorgTracker = metrics.NewReorgTracker(reorgWarnDepth)
 
 	observedPendingTxs = make(historicalRange, 0)
 
@@ -227,6 +240,11 @@ func fetchCurrentBlockData(ctx context.Context, ec *ethclient.Client, ms *monito
 		log.Debug().Msgf("Auto-adjusted batchSize to %d based on cache limit", newBatchSize)
 	}
 
+	// The head at the first poll is only the starting point of the TPS meter.
+	if ms.HeadBlock != nil {
+		observeNewHeads(ctx, ec.Client(), ms.TxMeter, ms.HeadBlock.Uint64(), cs.HeadBlock)
+	}
+
 	ms.HeadBlock = new(big.Int).SetUint64(cs.HeadBlock)
 	ms.ChainID = cs.ChainID
 	ms.PeerCount = cs.PeerCount
@@ -236,6 +254,42 @@ func fetchCurrentBlockData(ctx context.Context, ec *ethclient.Client, ms *monito
 	return
 }
 
+// observeNewHeads counts the transactions of the blocks after the previous head
+// up to the new head in the TPS meter, at the time they were seen. This only
+// covers new heads, rather than every block fetched while browsing.
+func observeNewHeads(ctx context.Context, rpc *ethrpc.Client, meter *metrics.TPSMeter, prev, head uint64) {
+	if head <= prev {
+		return
+	}
+	from := prev + 1
+	if head-prev > maxNewHeads {
+		from = head - maxNewHeads + 1
+	}
+
+	counts := make([]hexutil.Uint, head-from+1)
+	blms := make([]ethrpc.BatchElem, len(counts))
+	for i := range blms {
+		blms[i] = ethrpc.BatchElem{
+			Method: "eth_getBlockTransactionCountByNumber",
+			Args:   []interface{}{hexutil.EncodeUint64(from + uint64(i))},
+			Result: &counts[i],
+		}
+	}
+	if err := rpc.BatchCallContext(ctx, blms); err != nil {
+		log.Error().Err(err).Msg("Unable to fetch the transaction counts of new blocks")
+		return
+	}
+
+	now := time.Now()
+	for i, elem := range blms {
+		if elem.Error != nil {
+			log.Error().Err(elem.Error).Interface("Args", elem.Args).Msg("Failed batch element")
+			continue
+		}
+		meter.ObserveHead(from+uint64(i), now, int(counts[i]))
+	}
+}
+
 func (ms *monitorStatus) getBlockRange(ctx context.Context, to *big.Int, rpc *ethrpc.Client) error {
 	desiredBatchSize := new(big.Int).SetInt64(int64(batchSize.Get()))
 
@@ -347,6 +401,7 @@ func (ms *monitorStatus) processBatchesConcurrently(ctx context.Context, rpc *et
 					ms.BlocksLock.Lock()
 					ms.BlockCache.Add(pb.Number().String(), pb)
 					ms.BlocksLock.Unlock()
+					ms.ReorgTracker.Observe(pb)
 				}
 			}
 
@@ -463,7 +518,7 @@ func renderMonitorUI(ctx context.Context, ec *ethclient.Client, ms *monitorStatu
 		renderedBlocks = renderedBlocksTemp
 
 		log.Warn().Int("skeleton.Current.Inner.Dy()", skeleton.Current.Inner.Dy()).Int("skeleton.Current.Inner.Dx()", skeleton.Current.Inner.Dx()).Msg("the dimension of the current box")
-		skeleton.Current.Text = ui.GetCurrentBlockInfo(ms.HeadBlock, ms.GasPrice, ms.PeerCount, ms.PendingCount, ms.ChainID, renderedBlocks, skeleton.Current.Inner.Dx(), skeleton.Current.Inner.Dy())
+		skeleton.Current.Text = ui.GetCurrentBlockInfo(ms.HeadBlock, ms.GasPrice, ms.PeerCount, ms.PendingCount, ms.TxMeter.Current(), ms.ReorgTracker.Stats(), ms.ChainID, renderedBlocks, skeleton.Current.Inner.Dx(), skeleton.Current.Inner.Dy())
 		skeleton.TxPerBlockChart.Data = metrics.GetTxsPerBlock(renderedBlocks)
 		skeleton.GasPriceChart.Data = metrics.GetMeanGasPricePerBlock(renderedBlocks)
 		skeleton.BlockSizeChart.Data = metrics.GetSizePerBlock(renderedBlocks)
@@ -523,6 +578,8 @@ func renderMonitorUI(ctx context.Context, ec *ethclient.Client, ms *monitorStatu
 			switch e.ID {
 			case "q", "<C-c>":
 				return nil
+			case "r":
+				ms.ReorgTracker.Reset()
 			case "<Escape>":
 				if currentMode == monitorModeExplorer {
 					ms.TopDisplayedBlock = ms.HeadBlock
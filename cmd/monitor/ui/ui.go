@@ -31,7 +31,7 @@ type UiSkeleton struct {
 	Receipts        *widgets.List
 }
 
-func GetCurrentBlockInfo(headBlock *big.Int, gasPrice *big.Int, peerCount uint64, pendingCount uint64, chainID *big.Int, blocks []rpctypes.PolyBlock, dx int, dy int) string {
+func GetCurrentBlockInfo(headBlock *big.Int, gasPrice *big.Int, peerCount uint64, pendingCount uint64, tps float64, reorgs metrics.ReorgStats, chainID *big.Int, blocks []rpctypes.PolyBlock, dx int, dy int) string {
 	// Return an appropriate message if dy is 0 or less.
 	if dy <= 0 {
 		return "Invalid display configuration."
@@ -43,8 +43,10 @@ func GetCurrentBlockInfo(headBlock *big.Int, gasPrice *big.Int, peerCount uint64
 	peers := fmt.Sprintf("Peers: %d", peerCount)
 	pendingTx := fmt.Sprintf("Pending Tx: %d", pendingCount)
 	chainIdString := fmt.Sprintf("Chain ID: %s", chainID.String())
+	tpsString := fmt.Sprintf("TPS: %.2f", tps)
+	reorgString := reorgs.String()
 
-	info := []string{height, timeInfo, gasPriceString, peers, pendingTx, chainIdString}
+	info := []string{height, timeInfo, gasPriceString, peers, pendingTx, chainIdString, tpsString, reorgString}
 	columns := len(info) / dy
 	if len(info)%dy != 0 {
 		columns += 1 // Add an extra column for the remaining items
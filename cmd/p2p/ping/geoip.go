@@ -0,0 +1,70 @@
+package ping
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// geoIP looks up the country and autonomous system of IPs in MaxMind format
+// databases, e.g. GeoLite2-Country and GeoLite2-ASN. A nil *geoIP finds
+// nothing, so lookups don't have to check whether --geoip was given.
+type geoIP struct {
+	readers []*maxminddb.Reader
+}
+
+// geoRecord has the fields of the MaxMind country, city and ASN databases
+// that ping records. Each database fills in the fields it has.
+type geoRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	ASN uint `maxminddb:"autonomous_system_number"`
+}
+
+func openGeoIP(paths []string) (*geoIP, error) {
+	g := &geoIP{}
+	for _, path := range paths {
+		reader, err := maxminddb.Open(path)
+		if err != nil {
+			g.Close()
+			return nil, fmt.Errorf("unable to open geoip database %s: %w", path, err)
+		}
+		g.readers = append(g.readers, reader)
+	}
+	return g, nil
+}
+
+// lookup returns the ISO country code and the autonomous system number of the
+// IP from the first database that has each, or the zero values if none do.
+func (g *geoIP) lookup(ip net.IP) (string, uint) {
+	if g == nil || ip == nil {
+		return "", 0
+	}
+
+	var country string
+	var asn uint
+	for _, reader := range g.readers {
+		var record geoRecord
+		if err := reader.Lookup(ip, &record); err != nil {
+			continue
+		}
+		if country == "" {
+			country = record.Country.ISOCode
+		}
+		if asn == 0 {
+			asn = record.ASN
+		}
+	}
+	return country, asn
+}
+
+func (g *geoIP) Close() {
+	if g == nil {
+		return
+	}
+	for _, reader := range g.readers {
+		reader.Close()
+	}
+}
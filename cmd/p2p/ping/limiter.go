@@ -0,0 +1,32 @@
+package ping
+
+import (
+	"context"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// limiter bounds how many dial and handshake operations run at once. Every
+// connection attempt acquires from the same limiter, so the parallelism set
+// with --parallel holds no matter how the work is scheduled.
+type limiter struct {
+	sem *semaphore.Weighted
+}
+
+func newLimiter(n int) *limiter {
+	if n < 1 {
+		n = 1
+	}
+	return &limiter{sem: semaphore.NewWeighted(int64(n))}
+}
+
+// Acquire blocks until a slot is free or the context is done, in which case
+// the context's error is returned and no slot is held.
+func (l *limiter) Acquire(ctx context.Context) error {
+	return l.sem.Acquire(ctx, 1)
+}
+
+// Release frees a slot taken with Acquire.
+func (l *limiter) Release() {
+	l.sem.Release(1)
+}
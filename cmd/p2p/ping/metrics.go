@@ -0,0 +1,100 @@
+package ping
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog/log"
+
+	"github.com/maticnetwork/polygon-cli/p2p"
+)
+
+// pingMetrics are the Prometheus metrics exposed with --metrics-port.
+type pingMetrics struct {
+	registry   *prometheus.Registry
+	messages   *prometheus.CounterVec
+	handshakes *prometheus.CounterVec
+	peers      prometheus.Gauge
+}
+
+func newPingMetrics() *pingMetrics {
+	m := &pingMetrics{
+		registry: prometheus.NewRegistry(),
+		messages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "polycli",
+			Subsystem: "ping",
+			Name:      "messages_received_total",
+			Help:      "The number of messages received from peers by type.",
+		}, []string{"type"}),
+		handshakes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "polycli",
+			Subsystem: "ping",
+			Name:      "handshakes_total",
+			Help:      "The number of handshakes with peers by result.",
+		}, []string{"result"}),
+		peers: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "polycli",
+			Subsystem: "ping",
+			Name:      "connected_peers",
+			Help:      "The number of peers currently being listened to.",
+		}),
+	}
+	m.registry.MustRegister(m.messages, m.handshakes, m.peers)
+	return m
+}
+
+// addCounts adds a snapshot of the message counts to the message counters.
+func (m *pingMetrics) addCounts(c p2p.MessageCount) {
+	counts := map[string]int32{
+		"block_headers":         c.BlockHeaders,
+		"block_bodies":          c.BlockBodies,
+		"blocks":                c.Blocks,
+		"block_hashes":          c.BlockHashes,
+		"block_header_requests": c.BlockHeaderRequests,
+		"block_bodies_requests": c.BlockBodiesRequests,
+		"transactions":          c.Transactions,
+		"transaction_hashes":    c.TransactionHashes,
+		"transaction_requests":  c.TransactionRequests,
+		"pings":                 c.Pings,
+		"errors":                c.Errors,
+		"disconnects":           c.Disconnects,
+	}
+	for name, value := range counts {
+		if value > 0 {
+			m.messages.WithLabelValues(name).Add(float64(value))
+		}
+	}
+}
+
+// observeHandshake counts a handshake as a success or a failure.
+func (m *pingMetrics) observeHandshake(err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	m.handshakes.WithLabelValues(result).Inc()
+}
+
+// serve listens on the port and serves the metrics from it in the background.
+// Listening errors, such as the port being in use, are returned rather than
+// logged. The returned server must be closed when the metrics aren't needed.
+func (m *pingMetrics) serve(port int) (*http.Server, error) {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return nil, fmt.Errorf("unable to start metrics server: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	server := &http.Server{Handler: mux}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Err(err).Msg("Metrics server failed")
+		}
+	}()
+	return server, nil
+}
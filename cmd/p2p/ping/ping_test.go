@@ -0,0 +1,1128 @@
+package ping
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/forkid"
+	"github.com/ethereum/go-ethereum/crypto"
+	ethp2p "github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+	"github.com/ethereum/go-ethereum/p2p/rlpx"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/maticnetwork/polygon-cli/p2p"
+)
+
+func newTestNode(t *testing.T, ip string, port int) *enode.Node {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("could not generate key: %v", err)
+	}
+	return enode.NewV4(&key.PublicKey, net.ParseIP(ip), port, port)
+}
+
+func TestListenPorts(t *testing.T) {
+	node := newTestNode(t, "10.0.0.1", 30303)
+
+	type test struct {
+		name     string
+		hello    *p2p.Hello
+		mismatch bool
+	}
+
+	tests := []test{
+		{name: "nil hello", hello: nil, mismatch: false},
+		{name: "unset port", hello: &p2p.Hello{ListenPort: 0}, mismatch: false},
+		{name: "same port", hello: &p2p.Hello{ListenPort: 30303}, mismatch: false},
+		{name: "different port", hello: &p2p.Hello{ListenPort: 30304}, mismatch: true},
+	}
+
+	for _, test := range tests {
+		dialed, advertised, mismatch := listenPorts(node, test.hello)
+		if dialed != 30303 {
+			t.Errorf("%s: expected dialed port 30303, got %d", test.name, dialed)
+		}
+		if test.hello != nil && advertised != test.hello.ListenPort {
+			t.Errorf("%s: expected advertised port %d, got %d", test.name, test.hello.ListenPort, advertised)
+		}
+		if mismatch != test.mismatch {
+			t.Errorf("%s: expected mismatch %v, got %v", test.name, test.mismatch, mismatch)
+		}
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	const limit = 4
+	l := newLimiter(limit)
+
+	var (
+		wg      sync.WaitGroup
+		active  atomic.Int32
+		maxSeen atomic.Int32
+	)
+	for i := 0; i < 50; i++ {
+		if err := l.Acquire(context.Background()); err != nil {
+			t.Fatalf("could not acquire: %v", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				l.Release()
+				wg.Done()
+			}()
+
+			n := active.Add(1)
+			for {
+				m := maxSeen.Load()
+				if n <= m || maxSeen.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			active.Add(-1)
+		}()
+	}
+	wg.Wait()
+
+	if m := maxSeen.Load(); m > limit {
+		t.Errorf("expected at most %d concurrent dials, got %d", limit, m)
+	}
+
+	for i := 0; i < limit; i++ {
+		if err := l.Acquire(context.Background()); err != nil {
+			t.Fatalf("could not acquire: %v", err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.Acquire(ctx); err == nil {
+		t.Errorf("expected acquiring a full limiter with a done context to fail")
+	}
+}
+
+func TestWithinLatency(t *testing.T) {
+	type test struct {
+		name      string
+		latencyMs float64
+		min, max  time.Duration
+		within    bool
+	}
+
+	tests := []test{
+		{name: "no measurement", latencyMs: 0, max: time.Second, within: false},
+		{name: "below max", latencyMs: 50, max: 100 * time.Millisecond, within: true},
+		{name: "above max", latencyMs: 150, max: 100 * time.Millisecond, within: false},
+		{name: "below min", latencyMs: 5, min: 10 * time.Millisecond, within: false},
+		{name: "in band", latencyMs: 20, min: 10 * time.Millisecond, max: 100 * time.Millisecond, within: true},
+	}
+
+	for _, test := range tests {
+		if within := withinLatency(pingNodeJSON{LatencyMs: test.latencyMs}, test.min, test.max); within != test.within {
+			t.Errorf("%s: expected %v, got %v", test.name, test.within, within)
+		}
+	}
+}
+
+func TestStaticNodes(t *testing.T) {
+	ok := newTestNode(t, "10.0.0.1", 30303)
+	failed := newTestNode(t, "10.0.0.2", 30303)
+	noTCP := newTestNode(t, "10.0.0.3", 0)
+
+	output := pingNodeSet{
+		ok.ID():     {Record: ok},
+		failed.ID(): {Record: failed, Error: "dial tcp: connection refused"},
+		noTCP.ID():  {Record: noTCP},
+	}
+
+	urls, err := staticNodes(output)
+	if err != nil {
+		t.Fatalf("could not build static nodes: %v", err)
+	}
+	if len(urls) != 1 || urls[0] != ok.URLv4() {
+		t.Errorf("expected only %s, got %v", ok.URLv4(), urls)
+	}
+}
+
+func TestSkipExisting(t *testing.T) {
+	succeeded := newTestNode(t, "10.0.0.1", 30303)
+	failed := newTestNode(t, "10.0.0.2", 30303)
+	fresh := newTestNode(t, "10.0.0.3", 30303)
+
+	previous := pingNodeSet{
+		succeeded.ID(): {Record: succeeded, Hello: &p2p.Hello{Name: "geth"}},
+		failed.ID():    {Record: failed, Error: "i/o timeout"},
+	}
+	data, err := json.Marshal(previous)
+	if err != nil {
+		t.Fatalf("could not encode previous results: %v", err)
+	}
+	file := filepath.Join(t.TempDir(), "previous.json")
+	if err = os.WriteFile(file, data, 0644); err != nil {
+		t.Fatalf("could not write previous results: %v", err)
+	}
+
+	existing, err := readPingNodeSet(file)
+	if err != nil {
+		t.Fatalf("could not read previous results: %v", err)
+	}
+	if existing[succeeded.ID()].Hello == nil || existing[succeeded.ID()].Hello.Name != "geth" {
+		t.Errorf("expected the previous hello to be read back, got %+v", existing[succeeded.ID()])
+	}
+
+	remaining := skipSuccessful([]*enode.Node{succeeded, failed, fresh}, existing)
+	if len(remaining) != 2 || remaining[0].ID() != failed.ID() || remaining[1].ID() != fresh.ID() {
+		t.Errorf("expected the failed and fresh nodes to remain, got %v", remaining)
+	}
+}
+
+func TestSkipExistingSameFile(t *testing.T) {
+	succeeded := newTestNode(t, "10.0.0.1", 30303)
+	failed := newTestNode(t, "10.0.0.2", 30303)
+
+	// A previous ndjson run, which is both read with --skip-existing and
+	// written as the output.
+	file := filepath.Join(t.TempDir(), "results.ndjson")
+	var buf bytes.Buffer
+	if err := writeRecords(&buf, pingNodeSet{
+		succeeded.ID(): {Record: succeeded, Hello: &p2p.Hello{Name: "geth"}},
+		failed.ID():    {Record: failed, Error: "i/o timeout"},
+	}); err != nil {
+		t.Fatalf("could not encode previous results: %v", err)
+	}
+	if err := os.WriteFile(file, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("could not write previous results: %v", err)
+	}
+
+	// Do what ping does: read the previous results, create the output and
+	// write them first, then append the retried node's new result.
+	output, err := readPingNodeSet(file)
+	if err != nil {
+		t.Fatalf("could not read previous results: %v", err)
+	}
+	stream, err := os.Create(file)
+	if err != nil {
+		t.Fatalf("could not create output: %v", err)
+	}
+	if err = writeRecords(stream, output); err != nil {
+		t.Fatalf("could not write previous results: %v", err)
+	}
+	if err = writeRecord(stream, pingNodeJSON{Record: failed, Hello: &p2p.Hello{Name: "bor"}}); err != nil {
+		t.Fatalf("could not write new result: %v", err)
+	}
+	stream.Close()
+
+	results, err := readPingNodeSet(file)
+	if err != nil {
+		t.Fatalf("could not read results: %v", err)
+	}
+	if len(results) != 2 || results[succeeded.ID()].Hello == nil || results[succeeded.ID()].Hello.Name != "geth" {
+		t.Errorf("expected the previous successful result to be kept, got %+v", results)
+	}
+	if n := results[failed.ID()]; n.Error != "" || n.Hello == nil || n.Hello.Name != "bor" {
+		t.Errorf("expected the retried node's new result, got %+v", n)
+	}
+}
+
+func TestCheckGenesis(t *testing.T) {
+	genesis := common.HexToHash("0xa9c28ce2141b56c474f1dc504bee9b01eb1bd7d1a507580d5519d4437a97de1b")
+	other := common.HexToHash("0x7b66506a9ebdbf30d32b43c5f15a3b1216269a1ec3a75aa3182b86176a2b1ca7")
+
+	type test struct {
+		name     string
+		status   *p2p.Status
+		expected *common.Hash
+		mismatch bool
+	}
+
+	tests := []test{
+		{name: "matching", status: &p2p.Status{Genesis: genesis}, expected: &genesis, mismatch: false},
+		{name: "mismatched", status: &p2p.Status{Genesis: other}, expected: &genesis, mismatch: true},
+		{name: "no expected genesis", status: &p2p.Status{Genesis: other}, expected: nil, mismatch: false},
+		{name: "nil status", status: nil, expected: &genesis, mismatch: false},
+	}
+
+	for _, tc := range tests {
+		err := checkGenesis(tc.status, tc.expected)
+		if (err != nil) != tc.mismatch {
+			t.Errorf("%s: expected mismatch %v, got error %v", tc.name, tc.mismatch, err)
+		}
+		if err != nil && !strings.HasPrefix(err.Error(), "genesis mismatch") {
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+		}
+	}
+}
+
+func TestFilterGenesis(t *testing.T) {
+	genesis := common.HexToHash("0x01")
+	other := common.HexToHash("0x02")
+
+	if skipped := filterGenesis(&p2p.Status{Genesis: genesis}, &genesis); skipped != "" {
+		t.Errorf("expected a matching genesis not to be skipped, got %q", skipped)
+	}
+	if skipped := filterGenesis(nil, &genesis); skipped != "" {
+		t.Errorf("expected a missing status not to be skipped, got %q", skipped)
+	}
+
+	skipped := filterGenesis(&p2p.Status{Genesis: other}, &genesis)
+	if !strings.Contains(skipped, other.Hex()) {
+		t.Errorf("expected the skip reason to include the peer's genesis, got %q", skipped)
+	}
+
+	// Skipped peers are left out of the reachable count.
+	output := pingNodeSet{
+		newTestNode(t, "10.0.0.1", 30303).ID(): {},
+		newTestNode(t, "10.0.0.2", 30303).ID(): {Skipped: skipped, GenesisMismatch: true},
+	}
+	if reachable, total := countReachable(output); reachable != 1 || total != 1 {
+		t.Errorf("expected 1/1 nodes reachable, got %d/%d", reachable, total)
+	}
+}
+
+func TestParseGenesis(t *testing.T) {
+	if _, err := parseGenesis("0xa9c28ce2141b56c474f1dc504bee9b01eb1bd7d1a507580d5519d4437a97de1b"); err != nil {
+		t.Errorf("expected valid genesis, got %v", err)
+	}
+	for _, s := range []string{"", "0x1234", "a9c28ce2141b56c474f1dc504bee9b01eb1bd7d1a507580d5519d4437a97de1b"} {
+		if _, err := parseGenesis(s); err == nil {
+			t.Errorf("expected error parsing %q", s)
+		}
+	}
+}
+
+func TestCheckReachable(t *testing.T) {
+	output := pingNodeSet{
+		newTestNode(t, "10.0.0.1", 30303).ID(): {},
+		newTestNode(t, "10.0.0.2", 30303).ID(): {},
+		newTestNode(t, "10.0.0.3", 30303).ID(): {},
+		newTestNode(t, "10.0.0.4", 30303).ID(): {Error: "i/o timeout"},
+	}
+
+	reachable, total := countReachable(output)
+	if reachable != 3 || total != 4 {
+		t.Fatalf("expected 3/4 nodes reachable, got %d/%d", reachable, total)
+	}
+
+	type test struct {
+		name         string
+		minReachable float64
+		fail         bool
+	}
+
+	tests := []test{
+		{name: "no threshold", minReachable: 0, fail: false},
+		{name: "above threshold", minReachable: 0.5, fail: false},
+		{name: "at threshold", minReachable: 0.75, fail: false},
+		{name: "below threshold", minReachable: 0.9, fail: true},
+	}
+
+	for _, tc := range tests {
+		if err := checkReachable(reachable, total, tc.minReachable); (err != nil) != tc.fail {
+			t.Errorf("%s: expected failure %v, got error %v", tc.name, tc.fail, err)
+		}
+	}
+
+	if err := checkReachable(0, 0, 0.5); err == nil {
+		t.Errorf("expected an error without any nodes")
+	}
+}
+
+func TestApplyDisconnectAs(t *testing.T) {
+	type test struct {
+		mode      string
+		reachable int
+		total     int
+	}
+
+	tests := []test{
+		{mode: disconnectFailure, reachable: 1, total: 3},
+		{mode: disconnectSuccess, reachable: 2, total: 3},
+		{mode: disconnectNeutral, reachable: 1, total: 2},
+	}
+
+	for _, tc := range tests {
+		reason := &disconnectReason{Code: 4, Name: "too many peers"}
+		disconnected := pingNodeJSON{Hello: &p2p.Hello{}, Error: "disconnect received", DisconnectReason: reason}
+		// Disconnecting before the Hello is always a failure.
+		beforeHello := pingNodeJSON{Error: "disconnect received", DisconnectReason: reason}
+
+		output := pingNodeSet{
+			newTestNode(t, "10.0.0.1", 30303).ID(): {Hello: &p2p.Hello{}},
+			newTestNode(t, "10.0.0.2", 30303).ID(): disconnected,
+			newTestNode(t, "10.0.0.3", 30303).ID(): beforeHello,
+		}
+		for id, n := range output {
+			applyDisconnectAs(&n, tc.mode)
+			if n.DisconnectReason != output[id].DisconnectReason {
+				t.Errorf("%s: expected the disconnect reason to be kept", tc.mode)
+			}
+			output[id] = n
+		}
+
+		if reachable, total := countReachable(output); reachable != tc.reachable || total != tc.total {
+			t.Errorf("%s: expected %d/%d nodes reachable, got %d/%d", tc.mode, tc.reachable, tc.total, reachable, total)
+		}
+	}
+}
+
+func TestNewDisconnectReason(t *testing.T) {
+	if reason := newDisconnectReason(errors.New("connection reset")); reason != nil {
+		t.Errorf("expected no reason for other errors, got %+v", reason)
+	}
+
+	err := fmt.Errorf("listening: %w", &p2p.DisconnectError{Reason: 4})
+	reason := newDisconnectReason(err)
+	if reason == nil || reason.Code != 4 || reason.Name != "too many peers" {
+		t.Fatalf("expected code 4 too many peers, got %+v", reason)
+	}
+
+	data, err := json.Marshal(pingNodeJSON{DisconnectReason: reason})
+	if err != nil {
+		t.Fatalf("could not encode record: %v", err)
+	}
+	if !strings.Contains(string(data), `"disconnectReason":{"code":4,"name":"too many peers"}`) {
+		t.Errorf("unexpected encoding %s", data)
+	}
+	if data, _ = json.Marshal(pingNodeJSON{}); strings.Contains(string(data), "disconnectReason") {
+		t.Errorf("expected the reason to be omitted, got %s", data)
+	}
+}
+
+func TestNDJSONRecords(t *testing.T) {
+	succeeded := newTestNode(t, "10.0.0.1", 30303)
+	failed := newTestNode(t, "10.0.0.2", 30303)
+
+	var buf bytes.Buffer
+	for _, n := range []pingNodeJSON{
+		{Record: succeeded, Hello: &p2p.Hello{Name: "geth"}},
+		{Record: failed, Error: "i/o timeout"},
+	} {
+		if err := writeRecord(&buf, n); err != nil {
+			t.Fatalf("could not write record: %v", err)
+		}
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 2 {
+		t.Fatalf("expected 2 lines, got %d", lines)
+	}
+
+	// A run that crashed mid write leaves a partial last line.
+	buf.WriteString(`{"record": "enr:`)
+	file := filepath.Join(t.TempDir(), "results.ndjson")
+	if err := os.WriteFile(file, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("could not write results: %v", err)
+	}
+
+	existing, err := readPingNodeSet(file)
+	if err != nil {
+		t.Fatalf("could not read results: %v", err)
+	}
+	if len(existing) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(existing))
+	}
+	if existing[succeeded.ID()].Hello == nil || existing[succeeded.ID()].Hello.Name != "geth" {
+		t.Errorf("expected the hello to be read back, got %+v", existing[succeeded.ID()])
+	}
+	if existing[failed.ID()].Error != "i/o timeout" {
+		t.Errorf("expected the error to be read back, got %+v", existing[failed.ID()])
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	type test struct {
+		name      string
+		err       error
+		retryable bool
+	}
+
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", errors.New("connection refused"))}
+	tests := []test{
+		{name: "connection refused", err: refused, retryable: true},
+		{name: "timeout", err: fmt.Errorf("handshake failed: %w", os.ErrDeadlineExceeded), retryable: true},
+		{name: "closed", err: fmt.Errorf("status exchange failed: %w", io.EOF), retryable: true},
+		{name: "disconnect", err: fmt.Errorf("status exchange failed: %w", &p2p.DisconnectError{}), retryable: false},
+		{name: "protocol", err: errors.New("no shared eth protocol version"), retryable: false},
+	}
+
+	for _, tc := range tests {
+		if retryable(tc.err) != tc.retryable {
+			t.Errorf("%s: expected retryable %v", tc.name, tc.retryable)
+		}
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second, 30 * time.Second, 30 * time.Second}
+	for idx, backoff := range expected {
+		if got := retryBackoff(idx + 1); got != backoff {
+			t.Errorf("expected backoff %s after %d attempts, got %s", backoff, idx+1, got)
+		}
+	}
+}
+
+// refusingDialer fails every dial as if the node refused the connection.
+type refusingDialer struct {
+	dials atomic.Int32
+}
+
+func (d *refusingDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	d.dials.Add(1)
+	return nil, &net.OpError{Op: "dial", Net: network, Err: os.NewSyscallError("connect", errors.New("connection refused"))}
+}
+
+func TestPingRetryReleasesSlot(t *testing.T) {
+	dialer := &refusingDialer{}
+	limit := newLimiter(1)
+	p := &pinger{
+		params:  pingParams{Retries: 1, DisconnectAs: disconnectFailure},
+		dialer:  dialer,
+		metrics: newPingMetrics(),
+		limit:   limit,
+	}
+
+	if err := limit.Acquire(context.Background()); err != nil {
+		t.Fatalf("could not acquire slot: %v", err)
+	}
+	results := make(chan pingNodeJSON)
+	go func() {
+		results <- p.ping(context.Background(), newTestNode(t, "10.0.0.1", 30303), false)
+	}()
+
+	// The only slot is free while the node waits to be retried.
+	ctx, cancel := context.WithTimeout(context.Background(), retryBackoff(1)/2)
+	defer cancel()
+	if err := limit.Acquire(ctx); err != nil {
+		t.Fatalf("expected the slot to be released during the backoff: %v", err)
+	}
+	limit.Release()
+
+	result := <-results
+	if result.Attempts != 2 || dialer.dials.Load() != 2 || result.Error == "" {
+		t.Errorf("expected 2 failed attempts, got %d attempts, %d dials and error %q", result.Attempts, dialer.dials.Load(), result.Error)
+	}
+
+	// The ping took the slot back for the retry and released it when done.
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := limit.Acquire(ctx); err != nil {
+		t.Fatalf("could not acquire slot: %v", err)
+	}
+}
+
+func TestPingRetryCanceled(t *testing.T) {
+	dialer := &refusingDialer{}
+	limit := newLimiter(1)
+	p := &pinger{
+		params:  pingParams{Retries: 3, DisconnectAs: disconnectFailure},
+		dialer:  dialer,
+		metrics: newPingMetrics(),
+		limit:   limit,
+	}
+
+	if err := limit.Acquire(context.Background()); err != nil {
+		t.Fatalf("could not acquire slot: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results := make(chan pingNodeJSON, 1)
+	go func() {
+		results <- p.ping(ctx, newTestNode(t, "10.0.0.1", 30303), false)
+	}()
+
+	// Take the slot while the node backs off, so it can't get it back, and
+	// then cancel the command.
+	if err := limit.Acquire(context.Background()); err != nil {
+		t.Fatalf("could not acquire slot: %v", err)
+	}
+	time.Sleep(retryBackoff(1) + 100*time.Millisecond)
+	cancel()
+
+	select {
+	case result := <-results:
+		if dialer.dials.Load() != 1 || result.Error != context.Canceled.Error() {
+			t.Errorf("expected the ping to stop without retrying, got %d dials and error %q", dialer.dials.Load(), result.Error)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the ping to return when the command is canceled")
+	}
+
+	// The ping gave up without the slot, so only ours is held.
+	limit.Release()
+	acquireCtx, acquireCancel := context.WithTimeout(context.Background(), time.Second)
+	defer acquireCancel()
+	if err := limit.Acquire(acquireCtx); err != nil {
+		t.Fatalf("could not acquire slot: %v", err)
+	}
+}
+
+func TestPingListenPastTimeout(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("could not generate key: %v", err)
+	}
+
+	// The mock peer completes the handshake and status exchange, then keeps
+	// the connection open until the client closes it.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	defer listener.Close()
+	closed := make(chan time.Time, 1)
+	go func() {
+		fd, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer fd.Close()
+		conn := rlpx.NewConn(fd, nil)
+		if _, err = conn.Handshake(key); err != nil {
+			return
+		}
+
+		write := func(code uint64, msg any) {
+			payload, err := rlp.EncodeToBytes(msg)
+			if err == nil {
+				_, _ = conn.Write(code, payload)
+			}
+		}
+		pub := crypto.FromECDSAPub(&key.PublicKey)[1:]
+		write(uint64(p2p.Hello{}.Code()), &p2p.Hello{Version: 5, Name: "mock/v1.0.0", Caps: []ethp2p.Cap{{Name: "eth", Version: 68}}, ID: pub})
+		if _, _, _, err = conn.Read(); err != nil {
+			return
+		}
+		conn.SetSnappy(true)
+		write(uint64(p2p.Status{}.Code()), &p2p.Status{ProtocolVersion: 68, NetworkID: 137, TD: big.NewInt(1)})
+
+		for {
+			if _, _, _, err = conn.Read(); err != nil {
+				closed <- time.Now()
+				return
+			}
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	node := enode.NewV4(&key.PublicKey, addr.IP, addr.Port, addr.Port)
+	p := &pinger{
+		params:  pingParams{Timeout: 100 * time.Millisecond, Protocols: []uint{68}, DisconnectAs: disconnectFailure},
+		dialer:  &net.Dialer{},
+		metrics: newPingMetrics(),
+		count:   &p2p.MessageCount{},
+		limit:   newLimiter(1),
+	}
+
+	if err = p.limit.Acquire(context.Background()); err != nil {
+		t.Fatalf("could not acquire slot: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results := make(chan pingNodeJSON, 1)
+	go func() {
+		results <- p.ping(ctx, node, true)
+	}()
+
+	// The node is still listened to well past its timeout.
+	select {
+	case at := <-closed:
+		t.Fatalf("expected the connection to stay open past the timeout, closed at %s", at)
+	case result := <-results:
+		t.Fatalf("expected the node to be listened to past the timeout, got %+v", result)
+	case <-time.After(10 * p.params.Timeout):
+	}
+
+	// The deadline still stops listening.
+	cancel()
+	select {
+	case result := <-results:
+		if result.Hello == nil || result.Status == nil || result.Error != "" {
+			t.Errorf("expected a successful ping, got %+v", result)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected listening to stop at the deadline")
+	}
+}
+
+func TestPingMetrics(t *testing.T) {
+	m := newPingMetrics()
+	m.addCounts(p2p.MessageCount{Pings: 2, Transactions: 3})
+	m.addCounts(p2p.MessageCount{Pings: 1})
+	m.observeHandshake(nil)
+	m.observeHandshake(errors.New("dial failed"))
+	m.observeHandshake(errors.New("dial failed"))
+	m.peers.Inc()
+
+	type test struct {
+		metric   prometheus.Collector
+		expected float64
+	}
+
+	tests := []test{
+		{metric: m.messages.WithLabelValues("pings"), expected: 3},
+		{metric: m.messages.WithLabelValues("transactions"), expected: 3},
+		{metric: m.handshakes.WithLabelValues("success"), expected: 1},
+		{metric: m.handshakes.WithLabelValues("failure"), expected: 2},
+		{metric: m.peers, expected: 1},
+	}
+
+	for idx, tc := range tests {
+		if got := testutil.ToFloat64(tc.metric); got != tc.expected {
+			t.Errorf("%d: expected %v, got %v", idx, tc.expected, got)
+		}
+	}
+	if got := testutil.CollectAndCount(m.messages); got != 2 {
+		t.Errorf("expected only the received message types to be counted, got %d", got)
+	}
+}
+
+func TestPingMetricsServe(t *testing.T) {
+	m := newPingMetrics()
+	m.observeHandshake(nil)
+
+	// A port that's in use is an error rather than only being logged.
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	if _, err = m.serve(port); err == nil {
+		t.Errorf("expected an error for a port in use")
+	}
+	listener.Close()
+
+	server, err := m.serve(port)
+	if err != nil {
+		t.Fatalf("could not serve metrics: %v", err)
+	}
+	resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/metrics", port))
+	if err != nil {
+		t.Fatalf("could not get metrics: %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("could not read metrics: %v", err)
+	}
+	if !strings.Contains(string(body), `polycli_ping_handshakes_total{result="success"} 1`) {
+		t.Errorf("expected the handshake metric, got %s", body)
+	}
+
+	if err = server.Close(); err != nil {
+		t.Errorf("could not close server: %v", err)
+	}
+	if _, err = http.Get(fmt.Sprintf("http://127.0.0.1:%d/metrics", port)); err == nil {
+		t.Errorf("expected the server to stop when closed")
+	}
+}
+
+func TestReadNodes(t *testing.T) {
+	first := newTestNode(t, "10.0.0.1", 30303)
+	second := newTestNode(t, "10.0.0.2", 30303)
+	input := strings.Join([]string{
+		"# bootnodes",
+		first.URLv4(),
+		"",
+		"  " + second.URLv4() + "  ",
+		"enode://invalid",
+	}, "\n")
+
+	nodes, invalid, err := readNodes(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("could not read nodes: %v", err)
+	}
+	if len(nodes) != 2 || nodes[0].ID() != first.ID() || nodes[1].ID() != second.ID() {
+		t.Errorf("expected the two valid nodes, got %v", nodes)
+	}
+
+	n, ok := invalid[inputID("enode://invalid")]
+	if len(invalid) != 1 || !ok || n.Input != "enode://invalid" || n.Error == "" {
+		t.Fatalf("expected the invalid line to be recorded as an error, got %v", invalid)
+	}
+
+	// Invalid entries can be read back from ndjson output.
+	var buf bytes.Buffer
+	if err = writeRecord(&buf, n); err != nil {
+		t.Fatalf("could not write record: %v", err)
+	}
+	output, err := decodeRecords(buf.Bytes())
+	if err != nil {
+		t.Fatalf("could not decode records: %v", err)
+	}
+	if decoded, ok := output[inputID("enode://invalid")]; !ok || decoded.Error != n.Error {
+		t.Errorf("expected the invalid entry to round trip, got %v", output)
+	}
+}
+
+func TestDedupAndSortNodes(t *testing.T) {
+	a := newTestNode(t, "10.0.0.2", 30303)
+	b := newTestNode(t, "10.0.0.1", 30304)
+	c := newTestNode(t, "10.0.0.1", 30303)
+	d := newTestNode(t, "9.0.0.1", 30303)
+
+	nodes, duplicates := dedupNodes([]*enode.Node{a, b, a, c, b, d})
+	if duplicates != 2 {
+		t.Errorf("expected 2 duplicates, got %d", duplicates)
+	}
+	expected := []*enode.Node{a, b, c, d}
+	if len(nodes) != len(expected) {
+		t.Fatalf("expected %d nodes, got %d", len(expected), len(nodes))
+	}
+	for idx, n := range expected {
+		if nodes[idx].ID() != n.ID() {
+			t.Errorf("%d: expected the input order to be kept, got %s", idx, nodes[idx].URLv4())
+		}
+	}
+
+	sortNodes(nodes)
+	expected = []*enode.Node{d, c, b, a}
+	for idx, n := range expected {
+		if nodes[idx].ID() != n.ID() {
+			t.Errorf("%d: expected %s, got %s", idx, n.URLv4(), nodes[idx].URLv4())
+		}
+	}
+}
+
+func TestGeoIP(t *testing.T) {
+	var geo *geoIP
+	if country, asn := geo.lookup(net.ParseIP("1.1.1.1")); country != "" || asn != 0 {
+		t.Errorf("expected nothing without a database, got %q %d", country, asn)
+	}
+
+	if _, err := openGeoIP([]string{filepath.Join(t.TempDir(), "missing.mmdb")}); err == nil {
+		t.Errorf("expected an error for a missing database")
+	}
+
+	// The fixtures, written by testdata/mmdb.go, cover 1.0.0.0/8, one with the
+	// country and the other with the ASN, so the lookup merges them.
+	geo, err := openGeoIP([]string{"testdata/country.mmdb", "testdata/asn.mmdb"})
+	if err != nil {
+		t.Fatalf("could not open databases: %v", err)
+	}
+	defer geo.Close()
+
+	type test struct {
+		ip      string
+		country string
+		asn     uint
+	}
+	tests := []test{
+		{ip: "1.1.1.1", country: "AU", asn: 13335},
+		{ip: "1.255.0.1", country: "AU", asn: 13335},
+		{ip: "8.8.8.8"},
+		{ip: "2001:db8::1"},
+	}
+	for _, tc := range tests {
+		if country, asn := geo.lookup(net.ParseIP(tc.ip)); country != tc.country || asn != tc.asn {
+			t.Errorf("%s: expected %q %d, got %q %d", tc.ip, tc.country, tc.asn, country, asn)
+		}
+	}
+
+	data, err := json.Marshal(pingNodeJSON{})
+	if err != nil {
+		t.Fatalf("could not encode record: %v", err)
+	}
+	if strings.Contains(string(data), "country") || strings.Contains(string(data), "asn") {
+		t.Errorf("expected the geoip fields to be omitted, got %s", data)
+	}
+}
+
+func TestFilterForkID(t *testing.T) {
+	// newForkNode returns a node whose record has an eth entry with the fork ID.
+	newForkNode := func(hash [4]byte) *enode.Node {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatalf("could not generate key: %v", err)
+		}
+		var r enr.Record
+		r.Set(enr.IPv4(net.ParseIP("10.0.0.1")))
+		r.Set(enr.TCP(30303))
+		r.Set(p2p.ENREntry{ForkID: forkid.ID{Hash: hash}})
+		if err = enode.SignV4(&r, key); err != nil {
+			t.Fatalf("could not sign record: %v", err)
+		}
+		node, err := enode.New(enode.ValidSchemes, &r)
+		if err != nil {
+			t.Fatalf("could not create node: %v", err)
+		}
+		return node
+	}
+
+	matching := newForkNode([4]byte{0xdc, 0x08, 0x86, 0x5c})
+	other := newForkNode([4]byte{0x01, 0x02, 0x03, 0x04})
+	plain := newTestNode(t, "10.0.0.2", 30303)
+	nodes := []*enode.Node{matching, other, plain}
+
+	type test struct {
+		name      string
+		forkID    []byte
+		require   bool
+		remaining []*enode.Node
+	}
+
+	tests := []test{
+		{name: "fork ID", forkID: []byte{0xdc, 0x08, 0x86, 0x5c}, remaining: []*enode.Node{matching, plain}},
+		{name: "required fork ID", forkID: []byte{0xdc, 0x08, 0x86, 0x5c}, require: true, remaining: []*enode.Node{matching}},
+		{name: "only required", require: true, remaining: []*enode.Node{matching, other}},
+	}
+
+	for _, tc := range tests {
+		remaining, skipped := filterForkID(nodes, tc.forkID, tc.require)
+		if len(remaining) != len(tc.remaining) || len(remaining)+len(skipped) != len(nodes) {
+			t.Errorf("%s: expected %d remaining nodes, got %d and %d skipped", tc.name, len(tc.remaining), len(remaining), len(skipped))
+			continue
+		}
+		for idx, n := range tc.remaining {
+			if remaining[idx].ID() != n.ID() {
+				t.Errorf("%s: %d: expected %s, got %s", tc.name, idx, n.ID(), remaining[idx].ID())
+			}
+		}
+		for _, n := range skipped {
+			if n.Skipped == "" || n.Error != "" {
+				t.Errorf("%s: expected the node to be recorded as skipped, got %+v", tc.name, n)
+			}
+		}
+		if reachable, total := countReachable(skipped); reachable != 0 || total != 0 {
+			t.Errorf("%s: expected skipped nodes to be left out of the count, got %d/%d", tc.name, reachable, total)
+		}
+	}
+}
+
+func TestFeedNodes(t *testing.T) {
+	nodes := []*enode.Node{newTestNode(t, "10.0.0.1", 30303), newTestNode(t, "10.0.0.2", 30303)}
+
+	next := make(chan *enode.Node, len(nodes))
+	if err := feedNodes(context.Background(), nodes, next); err != nil || len(next) != len(nodes) {
+		t.Errorf("expected all of the nodes to be fed, got %d (%v)", len(next), err)
+	}
+
+	// Once the deadline is reached, feeding stops instead of blocking.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := feedNodes(ctx, nodes, make(chan *enode.Node)); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the context's error, got %v", err)
+	}
+}
+
+func TestNewProxyDialer(t *testing.T) {
+	for _, invalid := range []string{"http://127.0.0.1:8080", "://"} {
+		if _, err := newProxyDialer(invalid); err == nil {
+			t.Errorf("%s: expected an error", invalid)
+		}
+	}
+
+	// The target answers with a greeting once connected.
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	defer target.Close()
+	go func() {
+		c, err := target.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		_, _ = c.Write([]byte("hello"))
+	}()
+
+	// A minimal SOCKS5 proxy without authentication that only connects to
+	// IPv4 addresses.
+	socks, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	defer socks.Close()
+	var proxied int32
+	go func() {
+		c, err := socks.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+
+		greeting := make([]byte, 3)
+		if _, err = io.ReadFull(c, greeting); err != nil {
+			return
+		}
+		_, _ = c.Write([]byte{5, 0})
+
+		request := make([]byte, 10)
+		if _, err = io.ReadFull(c, request); err != nil || request[3] != 1 {
+			return
+		}
+		addr := &net.TCPAddr{IP: net.IP(request[4:8]), Port: int(request[8])<<8 | int(request[9])}
+		upstream, err := net.Dial("tcp", addr.String())
+		if err != nil {
+			return
+		}
+		defer upstream.Close()
+		atomic.AddInt32(&proxied, 1)
+		_, _ = c.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+		_, _ = io.Copy(c, upstream)
+	}()
+
+	dialer, err := newProxyDialer("socks5://" + socks.Addr().String())
+	if err != nil {
+		t.Fatalf("could not create proxy dialer: %v", err)
+	}
+	conn, err := dialer.DialContext(context.Background(), "tcp", target.Addr().String())
+	if err != nil {
+		t.Fatalf("could not dial through the proxy: %v", err)
+	}
+	defer conn.Close()
+
+	greeting := make([]byte, 5)
+	if _, err = io.ReadFull(conn, greeting); err != nil || string(greeting) != "hello" {
+		t.Errorf("expected the target's greeting, got %q (%v)", greeting, err)
+	}
+	if atomic.LoadInt32(&proxied) != 1 {
+		t.Errorf("expected the connection to go through the proxy")
+	}
+}
+
+func TestCapabilityCounts(t *testing.T) {
+	hello := func(caps ...ethp2p.Cap) *p2p.Hello { return &p2p.Hello{Caps: caps} }
+	output := pingNodeSet{
+		newTestNode(t, "10.0.0.1", 30303).ID(): {Hello: hello(ethp2p.Cap{Name: "eth", Version: 68}, ethp2p.Cap{Name: "snap", Version: 1})},
+		// Duplicates and the case of the name are normalized.
+		newTestNode(t, "10.0.0.2", 30303).ID(): {Hello: hello(ethp2p.Cap{Name: "ETH", Version: 68}, ethp2p.Cap{Name: "eth", Version: 68}, ethp2p.Cap{Name: "eth", Version: 67})},
+		newTestNode(t, "10.0.0.3", 30303).ID(): {Error: "connection refused"},
+	}
+
+	caps := hello(ethp2p.Cap{Name: "snap", Version: 1}, ethp2p.Cap{Name: "ETH", Version: 68}, ethp2p.Cap{Name: "eth", Version: 68}).Capabilities()
+	if strings.Join(caps, ",") != "eth/68,snap/1" {
+		t.Errorf("expected sorted unique capabilities, got %v", caps)
+	}
+
+	counts, nodes := capabilityCounts(output)
+	if nodes != 2 || counts["eth/68"] != 2 || counts["eth/67"] != 1 || counts["snap/1"] != 1 || len(counts) != 3 {
+		t.Errorf("unexpected capability counts %v for %d nodes", counts, nodes)
+	}
+
+	summary := formatHistogram("Capabilities", counts, nodes)
+	expected := "Capabilities of 2 nodes:\n" +
+		"  eth/68      2 100.0% ########################################\n" +
+		"  eth/67      1  50.0% ####################\n" +
+		"  snap/1      1  50.0% ####################\n"
+	if summary != expected {
+		t.Errorf("unexpected summary:\n%s", summary)
+	}
+}
+
+func TestClientCounts(t *testing.T) {
+	output := pingNodeSet{
+		newTestNode(t, "10.0.0.1", 30303).ID(): {Hello: &p2p.Hello{Name: "Geth/v1.13.5-stable/linux-amd64/go1.21.4"}},
+		newTestNode(t, "10.0.0.2", 30303).ID(): {Hello: &p2p.Hello{Name: "geth/mynode/v1.13.4/linux-arm64/go1.21.1"}},
+		newTestNode(t, "10.0.0.3", 30303).ID(): {Hello: &p2p.Hello{Name: "bor/v1.2.0/linux-amd64/go1.21"}},
+		newTestNode(t, "10.0.0.4", 30303).ID(): {Hello: &p2p.Hello{Name: "custom"}},
+		newTestNode(t, "10.0.0.5", 30303).ID(): {Error: "connection refused"},
+	}
+
+	counts, nodes := clientCounts(output)
+	if nodes != 4 || counts["geth"] != 2 || counts["bor"] != 1 || counts[p2p.UnknownClient] != 1 || len(counts) != 3 {
+		t.Errorf("unexpected client counts %v for %d nodes", counts, nodes)
+	}
+}
+
+func TestMonitorNodes(t *testing.T) {
+	a, b := newTestNode(t, "10.0.0.1", 30303), newTestNode(t, "10.0.0.2", 30303)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The results of each node in each cycle, where a nil Hello is a failure.
+	type response struct {
+		hello string
+		head  byte
+	}
+	responses := map[enode.ID][]response{
+		a.ID(): {{hello: "geth/v1", head: 1}, {hello: "geth/v1", head: 2}, {}},
+		b.ID(): {{}, {hello: "bor/v1", head: 1}, {hello: "bor/v2", head: 1}},
+	}
+
+	var mutex sync.Mutex
+	calls := make(map[enode.ID]int)
+	limit := newLimiter(2)
+	ping := func(ctx context.Context, node *enode.Node) pingNodeJSON {
+		defer limit.Release()
+		mutex.Lock()
+		cycle := calls[node.ID()]
+		calls[node.ID()]++
+		mutex.Unlock()
+
+		if cycle >= len(responses[node.ID()]) {
+			cancel()
+			return pingNodeJSON{Record: node, Error: ctx.Err().Error()}
+		}
+		r := responses[node.ID()][cycle]
+		if r.hello == "" {
+			return pingNodeJSON{Record: node, Error: "connection refused"}
+		}
+		return pingNodeJSON{
+			Record: node,
+			Hello:  &p2p.Hello{Name: r.hello},
+			Status: &p2p.Status{Head: common.Hash{r.head}},
+		}
+	}
+
+	// The invalid input is written in every cycle without being pinged.
+	invalid := pingNodeJSON{Input: "enode://bad", Error: "invalid node"}
+	unpinged := pingNodeSet{inputID(invalid.Input): invalid}
+
+	var buf bytes.Buffer
+	if err := monitorNodes(ctx, []*enode.Node{a, b}, unpinged, time.Millisecond, limit, ping, &buf); err != nil {
+		t.Fatalf("monitorNodes failed: %v", err)
+	}
+
+	var cycles []pingCycleJSON
+	decoder := json.NewDecoder(&buf)
+	for decoder.More() {
+		var cycle pingCycleJSON
+		if err := decoder.Decode(&cycle); err != nil {
+			t.Fatalf("could not decode cycle: %v", err)
+		}
+		cycles = append(cycles, cycle)
+	}
+	// The cycle cut short by the cancellation isn't written.
+	if len(cycles) != 3 {
+		t.Fatalf("expected 3 cycles, got %d", len(cycles))
+	}
+
+	type changes struct{ hello, status bool }
+	expected := []map[enode.ID]changes{
+		{a.ID(): {}, b.ID(): {}},
+		{a.ID(): {status: true}, b.ID(): {}},
+		{a.ID(): {}, b.ID(): {hello: true}},
+	}
+	for idx, cycle := range cycles {
+		if cycle.Cycle != idx+1 || cycle.Time.IsZero() || len(cycle.Nodes) != 3 {
+			t.Errorf("unexpected cycle %d: %+v", idx+1, cycle)
+		}
+		if got := cycle.Nodes[inputID(invalid.Input)]; got.Input != invalid.Input || got.Error != invalid.Error {
+			t.Errorf("cycle %d: expected the invalid input, got %+v", idx+1, got)
+		}
+		for id, want := range expected[idx] {
+			got := cycle.Nodes[id]
+			if got.HelloChanged != want.hello || got.StatusChanged != want.status {
+				t.Errorf("cycle %d: expected changes %+v, got hello %t status %t", idx+1, want, got.HelloChanged, got.StatusChanged)
+			}
+		}
+	}
+}
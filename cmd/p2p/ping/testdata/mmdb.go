@@ -0,0 +1,96 @@
+//go:build ignore
+
+// This program writes the MaxMind DB fixtures TestGeoIP reads: a country
+// database and an ASN database that both cover 1.0.0.0/8. Run it from the
+// ping package with go run testdata/mmdb.go.
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"log"
+	"os"
+)
+
+// network is the first octet of the /8 the databases cover.
+const (
+	network = 1
+	prefix  = 8
+)
+
+func main() {
+	country := mapValue("country", mapValue("iso_code", stringValue("AU")))
+	asn := mapValue("autonomous_system_number", uintValue(6, 13335))
+	if err := os.WriteFile("testdata/country.mmdb", database("GeoLite2-Country", country), 0644); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("testdata/asn.mmdb", database("GeoLite2-ASN", asn), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// database returns an IPv4 database with 24 bit records where the addresses in
+// 1.0.0.0/8 have the record and the others have none.
+func database(dbType string, record []byte) []byte {
+	var buf bytes.Buffer
+
+	// One node per bit of the prefix. The record for the bit that matches 1 is
+	// the next node, or the data for the last one, and the other is empty,
+	// which is a pointer to the node count.
+	nodes := uint32(prefix)
+	for depth := uint32(0); depth < prefix; depth++ {
+		next := depth + 1
+		if depth == prefix-1 {
+			next = nodes + 16
+		}
+		left, right := nodes, nodes
+		if network>>(prefix-1-depth)&1 == 1 {
+			right = next
+		} else {
+			left = next
+		}
+		buf.Write(uint24(left))
+		buf.Write(uint24(right))
+	}
+
+	buf.Write(make([]byte, 16))
+	buf.Write(record)
+
+	buf.WriteString("\xab\xcd\xefMaxMind.com")
+	buf.Write(mapValue(
+		"node_count", uintValue(6, uint64(nodes)),
+		"record_size", uintValue(5, 24),
+		"ip_version", uintValue(5, 4),
+		"database_type", stringValue(dbType),
+		"binary_format_major_version", uintValue(5, 2),
+		"binary_format_minor_version", uintValue(5, 0),
+	))
+	return buf.Bytes()
+}
+
+func uint24(v uint32) []byte {
+	return []byte{byte(v >> 16), byte(v >> 8), byte(v)}
+}
+
+func stringValue(s string) []byte {
+	return append([]byte{2<<5 | byte(len(s))}, s...)
+}
+
+// uintValue encodes v as the unsigned type, 5 for uint16 or 6 for uint32, in
+// as few bytes as it needs.
+func uintValue(typ byte, v uint64) []byte {
+	var data [8]byte
+	binary.BigEndian.PutUint64(data[:], v)
+	trimmed := bytes.TrimLeft(data[:], "\x00")
+	return append([]byte{typ<<5 | byte(len(trimmed))}, trimmed...)
+}
+
+// mapValue encodes the key and value pairs as a map.
+func mapValue(pairs ...any) []byte {
+	data := []byte{7<<5 | byte(len(pairs)/2)}
+	for i := 0; i < len(pairs); i += 2 {
+		data = append(data, stringValue(pairs[i].(string))...)
+		data = append(data, pairs[i+1].([]byte)...)
+	}
+	return data
+}
@@ -1,7 +1,6 @@
 package rpc
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/url"
 	"strings"
@@ -10,6 +9,8 @@ import (
 
 	ethrpc "github.com/ethereum/go-ethereum/rpc"
 
+	"github.com/maticnetwork/polygon-cli/util"
+
 	"github.com/spf13/cobra"
 )
 
@@ -29,17 +30,11 @@ var RpcCmd = &cobra.Command{
 		}
 
 		params := toGenericParams(args[2:])
-		var res = new(json.RawMessage)
-		err = ec.Call(res, args[1], params...)
-		if err != nil {
-			return err
-		}
-		body, err := res.MarshalJSON()
+		res, err := util.Call(ctx, ec, args[1], params...)
 		if err != nil {
-			fmt.Println("gyahhhhhhhh1")
 			return err
 		}
-		fmt.Println(string(body))
+		fmt.Println(string(res))
 		return nil
 	},
 	Args: func(cmd *cobra.Command, args []string) error {
@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	ethcommon "github.com/ethereum/go-ethereum/common"
+
+	"github.com/maticnetwork/polygon-cli/rpctypes"
+)
+
+// AccountSet is a set of accounts that have been seen before. It can be backed
+// by anything from a map to a persistent store or a bloom filter.
+type AccountSet interface {
+	Contains(address ethcommon.Address) bool
+	Add(address ethcommon.Address)
+}
+
+// MapAccountSet is an in memory AccountSet.
+type MapAccountSet map[ethcommon.Address]struct{}
+
+func (s MapAccountSet) Contains(address ethcommon.Address) bool {
+	_, ok := s[address]
+	return ok
+}
+
+func (s MapAccountSet) Add(address ethcommon.Address) {
+	s[address] = struct{}{}
+}
+
+// GetNewSenders returns the senders of the block's transactions that aren't in
+// the seen set, in the order they first appear, and adds them to the set.
+// Feeding blocks in order approximates how many accounts become active over
+// time. With a set that can report false positives, such as a bloom filter,
+// some new senders are taken to be seen already, so the result is a lower
+// bound.
+func GetNewSenders(block rpctypes.PolyBlock, seen AccountSet) []ethcommon.Address {
+	senders := make([]ethcommon.Address, 0)
+	for _, tx := range block.Transactions() {
+		from := tx.From()
+		if seen.Contains(from) {
+			continue
+		}
+		seen.Add(from)
+		senders = append(senders, from)
+	}
+	return senders
+}
@@ -0,0 +1,136 @@
+package metrics
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/maticnetwork/polygon-cli/rpctypes"
+)
+
+type (
+	// BlockAnomalyKind describes how a block number deviated from the expected
+	// previous+1 ordering.
+	BlockAnomalyKind int
+
+	// BlockAnomaly is emitted when a block in a stream isn't exactly one more
+	// than the highest block seen so far.
+	BlockAnomaly struct {
+		Kind     BlockAnomalyKind
+		Expected *big.Int
+		Actual   *big.Int
+		Hash     string
+	}
+
+	// ValidatedBlock pairs a block with the anomaly that was detected for it,
+	// if any.
+	ValidatedBlock struct {
+		Block   rpctypes.PolyBlock
+		Anomaly *BlockAnomaly
+	}
+
+	// BlockOrderValidator checks that a stream of blocks is contiguous. When
+	// Drop is set, anomalous blocks are removed from the filtered stream,
+	// otherwise they are passed through annotated with the anomaly. OnAnomaly
+	// is called for every anomaly regardless of the mode.
+	BlockOrderValidator struct {
+		Drop      bool
+		OnAnomaly func(BlockAnomaly)
+
+		last *big.Int
+	}
+)
+
+const (
+	// BlockGap means one or more block numbers were skipped.
+	BlockGap BlockAnomalyKind = iota
+	// BlockDuplicate means the block number was already seen.
+	BlockDuplicate
+	// BlockBackward means the block number is lower than one already seen,
+	// which usually indicates a reorg or a lagging source.
+	BlockBackward
+)
+
+func (k BlockAnomalyKind) String() string {
+	switch k {
+	case BlockGap:
+		return "gap"
+	case BlockDuplicate:
+		return "duplicate"
+	case BlockBackward:
+		return "backward"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(k))
+	}
+}
+
+func (a BlockAnomaly) String() string {
+	return fmt.Sprintf("%s: expected block %s but got %s (%s)", a.Kind, a.Expected, a.Actual, a.Hash)
+}
+
+// NewBlockOrderValidator creates a validator. If drop is true, anomalous blocks
+// are dropped by Filter rather than annotated.
+func NewBlockOrderValidator(drop bool) *BlockOrderValidator {
+	return &BlockOrderValidator{Drop: drop}
+}
+
+// Check validates the next block in the stream and returns the anomaly, or nil
+// if the block number is exactly the previous highest number plus one. The
+// first block checked is always accepted. Gaps advance the validator to the
+// new block so the following blocks aren't flagged as well, while duplicate
+// and backward blocks leave it unchanged.
+func (v *BlockOrderValidator) Check(block rpctypes.PolyBlock) *BlockAnomaly {
+	number := block.Number()
+	if v.last == nil {
+		v.last = number
+		return nil
+	}
+
+	expected := new(big.Int).Add(v.last, big.NewInt(1))
+	cmp := number.Cmp(expected)
+	if cmp == 0 {
+		v.last = number
+		return nil
+	}
+
+	anomaly := BlockAnomaly{
+		Expected: expected,
+		Actual:   number,
+		Hash:     block.Hash().Hex(),
+	}
+
+	switch {
+	case cmp > 0:
+		anomaly.Kind = BlockGap
+		v.last = number
+	case number.Cmp(v.last) == 0:
+		anomaly.Kind = BlockDuplicate
+	default:
+		anomaly.Kind = BlockBackward
+	}
+
+	if v.OnAnomaly != nil {
+		v.OnAnomaly(anomaly)
+	}
+
+	return &anomaly
+}
+
+// Filter consumes blocks from the input channel and forwards them to the
+// returned channel after validating their order. The returned channel is
+// closed once the input channel is closed.
+func (v *BlockOrderValidator) Filter(in <-chan rpctypes.PolyBlock) <-chan ValidatedBlock {
+	out := make(chan ValidatedBlock)
+
+	go func() {
+		defer close(out)
+		for block := range in {
+			anomaly := v.Check(block)
+			if anomaly != nil && v.Drop {
+				continue
+			}
+			out <- ValidatedBlock{Block: block, Anomaly: anomaly}
+		}
+	}()
+
+	return out
+}
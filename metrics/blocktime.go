@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+
+	"github.com/maticnetwork/polygon-cli/rpctypes"
+)
+
+// BlockTimeJitter describes how regularly blocks were produced.
+type BlockTimeJitter struct {
+	// StdDev is the standard deviation of the block time deltas in seconds.
+	StdDev float64
+	// OutsideTolerance is the fraction of the block time deltas that are
+	// further than the tolerance from the target block time.
+	OutsideTolerance float64
+}
+
+// GetBlockTimeDeltas returns the number of seconds between each block and the
+// one before it, after sorting the blocks by number.
+func GetBlockTimeDeltas(blocks []rpctypes.PolyBlock) []float64 {
+	bs := rpctypes.SortableBlocks(blocks)
+	sort.Sort(bs)
+
+	deltas := make([]float64, 0)
+	for i := 1; i < len(bs); i++ {
+		deltas = append(deltas, float64(bs[i].Time())-float64(bs[i-1].Time()))
+	}
+	return deltas
+}
+
+// GetBlockTimeJitter computes how far the block times stray from the target
+// block time, where the target and tolerance are in seconds. With fewer than
+// two blocks there are no block times, so the jitter is zero.
+func GetBlockTimeJitter(blocks []rpctypes.PolyBlock, target, tolerance float64) BlockTimeJitter {
+	deltas := GetBlockTimeDeltas(blocks)
+	if len(deltas) == 0 {
+		return BlockTimeJitter{}
+	}
+
+	var sum float64
+	outside := 0
+	for _, d := range deltas {
+		sum += d
+		if math.Abs(d-target) > tolerance {
+			outside++
+		}
+	}
+	mean := sum / float64(len(deltas))
+
+	var variance float64
+	for _, d := range deltas {
+		variance += (d - mean) * (d - mean)
+	}
+	variance /= float64(len(deltas))
+
+	return BlockTimeJitter{
+		StdDev:           math.Sqrt(variance),
+		OutsideTolerance: float64(outside) / float64(len(deltas)),
+	}
+}
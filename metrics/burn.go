@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/maticnetwork/polygon-cli/rpctypes"
+)
+
+// TotalBurned sums the base fees burned by the blocks, in wei, and also
+// returns the sum formatted in ether (or the chain's native token, e.g. POL).
+// Blocks from before London don't burn anything, so an empty range or one
+// without London blocks returns zero.
+func TotalBurned(blocks []rpctypes.PolyBlock) (*big.Int, string) {
+	total := new(big.Int)
+	for _, b := range blocks {
+		total.Add(total, b.BurnedFees())
+	}
+	return total, FormatEther(total)
+}
+
+// FormatEther formats an amount of wei in ether without losing precision,
+// dropping trailing zeros from the fraction.
+func FormatEther(wei *big.Int) string {
+	whole, frac := new(big.Int).QuoRem(new(big.Int).Abs(wei), UnitEther, new(big.Int))
+
+	sign := ""
+	if wei.Sign() < 0 {
+		sign = "-"
+	}
+	if frac.Sign() == 0 {
+		return sign + whole.String()
+	}
+	return fmt.Sprintf("%s%s.%s", sign, whole, strings.TrimRight(fmt.Sprintf("%018s", frac), "0"))
+}
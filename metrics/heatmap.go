@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/maticnetwork/polygon-cli/rpctypes"
+)
+
+// GasPriceHeatmap is a 2D histogram of transaction gas prices over a range of
+// blocks, for rendering as a heatmap. Counts[x][y] is the number of
+// transactions in block Blocks[x] with a gas price in bucket y, which covers
+// [Buckets[y], Buckets[y]+BucketSize).
+type GasPriceHeatmap struct {
+	Blocks     []uint64
+	Buckets    []*big.Int
+	BucketSize *big.Int
+	Counts     [][]uint64
+}
+
+// maxHeatmapBuckets bounds the number of gas price buckets, since every block
+// has a count for each bucket.
+const maxHeatmapBuckets = 10000
+
+// GetGasPriceHeatmap buckets the transactions of the blocks by block number
+// and gas price. Dynamic fee transactions are bucketed by their effective gas
+// price given the block's base fee, while legacy transactions and those in
+// blocks without a base fee use their gas price. The buckets go from the one
+// with the lowest gas price seen to the one with the highest, in steps of
+// bucketSize wei. An error is returned if that's more than maxHeatmapBuckets
+// buckets, e.g. with a bucket size far smaller than the spread of prices.
+func GetGasPriceHeatmap(blocks []rpctypes.PolyBlock, bucketSize *big.Int) (*GasPriceHeatmap, error) {
+	if bucketSize == nil || bucketSize.Sign() <= 0 {
+		return nil, fmt.Errorf("bucket size must be positive")
+	}
+
+	bs := make(rpctypes.SortableBlocks, len(blocks))
+	copy(bs, blocks)
+	sort.Sort(bs)
+
+	heatmap := &GasPriceHeatmap{
+		Blocks:     make([]uint64, len(bs)),
+		BucketSize: new(big.Int).Set(bucketSize),
+		Counts:     make([][]uint64, len(bs)),
+	}
+
+	// Bucket each transaction first, then size the rows once the lowest and
+	// highest buckets are known.
+	buckets := make([][]*big.Int, len(bs))
+	var minBucket, maxBucket *big.Int
+	for x, b := range bs {
+		heatmap.Blocks[x] = b.Number().Uint64()
+		baseFee := b.BaseFee()
+		for _, tx := range b.Transactions() {
+			bucket := new(big.Int).Div(rpctypes.EstimateEffectiveGasPrice(tx, baseFee, nil), bucketSize)
+			buckets[x] = append(buckets[x], bucket)
+			if minBucket == nil || bucket.Cmp(minBucket) < 0 {
+				minBucket = bucket
+			}
+			if maxBucket == nil || bucket.Cmp(maxBucket) > 0 {
+				maxBucket = bucket
+			}
+		}
+	}
+
+	count := 0
+	if minBucket != nil {
+		span := new(big.Int).Sub(maxBucket, minBucket)
+		if !span.IsInt64() || span.Int64() >= maxHeatmapBuckets {
+			return nil, fmt.Errorf("gas prices from %s to %s wei need more than %d buckets of %s wei",
+				new(big.Int).Mul(minBucket, bucketSize), new(big.Int).Mul(maxBucket, bucketSize), maxHeatmapBuckets, bucketSize)
+		}
+		count = int(span.Int64()) + 1
+	}
+
+	heatmap.Buckets = make([]*big.Int, count)
+	for y := range heatmap.Buckets {
+		bucket := new(big.Int).Add(minBucket, big.NewInt(int64(y)))
+		heatmap.Buckets[y] = bucket.Mul(bucket, bucketSize)
+	}
+	for x := range heatmap.Counts {
+		heatmap.Counts[x] = make([]uint64, count)
+		for _, bucket := range buckets[x] {
+			// The offset is within the bucket count checked above.
+			heatmap.Counts[x][new(big.Int).Sub(bucket, minBucket).Int64()]++
+		}
+	}
+
+	return heatmap, nil
+}
@@ -0,0 +1,531 @@
+package metrics
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/maticnetwork/polygon-cli/rpctypes"
+)
+
+func TestTPSMeter(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	meter := NewTPSMeter(10 * time.Second)
+
+	// Bursty blocks: 100 transactions in a block, then two quick blocks.
+	meter.Observe(start, 100)
+	meter.Observe(start.Add(1*time.Second), 20)
+	meter.Observe(start.Add(2*time.Second), 30)
+
+	if tps := meter.Rate(start.Add(5 * time.Second)); tps != 15 {
+		t.Errorf("expected 15 TPS, got %v", tps)
+	}
+
+	// The first block falls out of the window.
+	if tps := meter.Rate(start.Add(10 * time.Second)); tps != 5 {
+		t.Errorf("expected 5 TPS, got %v", tps)
+	}
+
+	// All blocks fall out of the window.
+	if tps := meter.Rate(start.Add(time.Minute)); tps != 0 {
+		t.Errorf("expected 0 TPS, got %v", tps)
+	}
+}
+
+func TestTPSMeterObserveHead(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	meter := NewTPSMeter(10 * time.Second)
+
+	if !meter.ObserveHead(100, start, 50) || !meter.ObserveHead(101, start.Add(time.Second), 50) {
+		t.Fatalf("expected new heads to be counted")
+	}
+	// Re-fetched and older blocks aren't counted again.
+	if meter.ObserveHead(101, start.Add(2*time.Second), 50) || meter.ObserveHead(90, start.Add(2*time.Second), 50) {
+		t.Errorf("expected a repeated or older block not to be counted")
+	}
+
+	if tps := meter.Rate(start.Add(5 * time.Second)); tps != 10 {
+		t.Errorf("expected 10 TPS, got %v", tps)
+	}
+}
+
+func TestGetGasPriceHeatmap(t *testing.T) {
+	blocks := []rpctypes.PolyBlock{
+		rpctypes.NewPolyBlock(&rpctypes.RawBlockResponse{
+			Number:        "0x2",
+			BaseFeePerGas: "0x14", // 20
+			Transactions: []rpctypes.RawTransactionResponse{
+				// Effective gas price 20 + 5 = 25.
+				{MaxFeePerGas: "0x64", MaxPriorityFeePerGas: "0x5"},
+				// Capped at the fee cap of 21.
+				{MaxFeePerGas: "0x15", MaxPriorityFeePerGas: "0xa"},
+			},
+		}),
+		rpctypes.NewPolyBlock(&rpctypes.RawBlockResponse{
+			Number: "0x1",
+			Transactions: []rpctypes.RawTransactionResponse{
+				{GasPrice: "0x3"},
+				{GasPrice: "0x9"},
+				{GasPrice: "0x2d"}, // 45
+			},
+		}),
+	}
+
+	if _, err := GetGasPriceHeatmap(blocks, big.NewInt(0)); err == nil {
+		t.Errorf("expected an error for a zero bucket size")
+	}
+
+	heatmap, err := GetGasPriceHeatmap(blocks, big.NewInt(10))
+	if err != nil {
+		t.Fatalf("could not build heatmap: %v", err)
+	}
+
+	if len(heatmap.Blocks) != 2 || heatmap.Blocks[0] != 1 || heatmap.Blocks[1] != 2 {
+		t.Errorf("expected blocks [1 2], got %v", heatmap.Blocks)
+	}
+	if len(heatmap.Buckets) != 5 || heatmap.Buckets[4].Int64() != 40 {
+		t.Errorf("expected 5 buckets up to 40, got %v", heatmap.Buckets)
+	}
+
+	expected := [][]uint64{
+		{2, 0, 0, 0, 1},
+		{0, 0, 2, 0, 0},
+	}
+	for x := range expected {
+		for y := range expected[x] {
+			if heatmap.Counts[x][y] != expected[x][y] {
+				t.Errorf("expected count %d at (%d, %d), got %d", expected[x][y], x, y, heatmap.Counts[x][y])
+			}
+		}
+	}
+}
+
+func TestGetGasPriceHeatmapRange(t *testing.T) {
+	gwei := big.NewInt(1_000_000_000)
+	block := func(number string, prices ...string) rpctypes.PolyBlock {
+		raw := &rpctypes.RawBlockResponse{Number: rpctypes.RawQuantityResponse(number)}
+		for _, price := range prices {
+			raw.Transactions = append(raw.Transactions, rpctypes.RawTransactionResponse{GasPrice: rpctypes.RawQuantityResponse(price)})
+		}
+		return rpctypes.NewPolyBlock(raw)
+	}
+
+	// The buckets start at the lowest price rather than zero, so a high price
+	// with a small spread only needs a few buckets.
+	blocks := []rpctypes.PolyBlock{
+		block("0x1", hexutil.EncodeBig(new(big.Int).Mul(big.NewInt(30), gwei))),
+		block("0x2", hexutil.EncodeBig(new(big.Int).Add(new(big.Int).Mul(big.NewInt(30), gwei), big.NewInt(2)))),
+	}
+	heatmap, err := GetGasPriceHeatmap(blocks, big.NewInt(1))
+	if err != nil {
+		t.Fatalf("could not build heatmap: %v", err)
+	}
+	if len(heatmap.Buckets) != 3 || heatmap.Buckets[0].Cmp(new(big.Int).Mul(big.NewInt(30), gwei)) != 0 {
+		t.Errorf("expected 3 buckets from 30 gwei, got %v", heatmap.Buckets)
+	}
+	if heatmap.Counts[0][0] != 1 || heatmap.Counts[1][2] != 1 {
+		t.Errorf("unexpected counts %v", heatmap.Counts)
+	}
+
+	// A 30 gwei spread in 1 wei buckets is far too many buckets.
+	blocks = append(blocks, block("0x3", "0x0"))
+	if _, err := GetGasPriceHeatmap(blocks, big.NewInt(1)); err == nil {
+		t.Errorf("expected an error for too many buckets")
+	}
+
+	// Prices above int64 don't overflow the bucket index.
+	huge := new(big.Int).Lsh(big.NewInt(1), 100)
+	heatmap, err = GetGasPriceHeatmap([]rpctypes.PolyBlock{block("0x1", hexutil.EncodeBig(huge))}, big.NewInt(1))
+	if err != nil {
+		t.Fatalf("could not build heatmap: %v", err)
+	}
+	if len(heatmap.Buckets) != 1 || heatmap.Buckets[0].Cmp(huge) != 0 || heatmap.Counts[0][0] != 1 {
+		t.Errorf("expected a single bucket at 2^100, got %v", heatmap.Buckets)
+	}
+
+	// Blocks without transactions have no buckets.
+	heatmap, err = GetGasPriceHeatmap([]rpctypes.PolyBlock{block("0x1")}, big.NewInt(1))
+	if err != nil || len(heatmap.Buckets) != 0 || len(heatmap.Counts[0]) != 0 {
+		t.Errorf("expected no buckets, got %v (%v)", heatmap, err)
+	}
+}
+
+func TestFindSandwiches(t *testing.T) {
+	pool := rpctypes.RawData20Response("0x00000000000000000000000000000000000000aa")
+	other := rpctypes.RawData20Response("0x00000000000000000000000000000000000000bb")
+	attacker := rpctypes.RawData20Response("0x0000000000000000000000000000000000000001")
+	user := rpctypes.RawData20Response("0x0000000000000000000000000000000000000002")
+
+	sandwiched := rpctypes.NewPolyBlock(&rpctypes.RawBlockResponse{
+		Transactions: []rpctypes.RawTransactionResponse{
+			{Hash: "0x10", From: user, To: other, Input: "0x01"},
+			{Hash: "0x11", From: attacker, To: pool, Input: "0x01"},
+			{Hash: "0x12", From: user, To: pool, Input: "0x01"},
+			{Hash: "0x13", From: attacker, To: pool, Input: "0x01"},
+			{Hash: "0x14", From: user, To: other, Input: "0x01"},
+		},
+	})
+	sandwiches := FindSandwiches(sandwiched)
+	if len(sandwiches) != 1 {
+		t.Fatalf("expected 1 sandwich, got %d", len(sandwiches))
+	}
+	s := sandwiches[0]
+	if s.Frontrun.Hash() != ethcommon.HexToHash("0x11") || s.Victim.Hash() != ethcommon.HexToHash("0x12") ||
+		s.Backrun.Hash() != ethcommon.HexToHash("0x13") || s.Target != pool.ToAddress() {
+		t.Errorf("unexpected sandwich %s, %s, %s targeting %s", s.Frontrun.Hash(), s.Victim.Hash(), s.Backrun.Hash(), s.Target)
+	}
+
+	clean := rpctypes.NewPolyBlock(&rpctypes.RawBlockResponse{
+		Transactions: []rpctypes.RawTransactionResponse{
+			{Hash: "0x20", From: attacker, To: pool, Input: "0x01"},
+			{Hash: "0x21", From: attacker, To: pool, Input: "0x01"},
+			{Hash: "0x22", From: user, To: pool, Input: "0x01"},
+			{Hash: "0x23", From: user, To: pool},
+		},
+	})
+	if sandwiches := FindSandwiches(clean); len(sandwiches) != 0 {
+		t.Errorf("expected no sandwiches in a clean block, got %d", len(sandwiches))
+	}
+}
+
+func TestTotalBurned(t *testing.T) {
+	blocks := []rpctypes.PolyBlock{
+		// Before London, nothing is burned.
+		rpctypes.NewPolyBlock(&rpctypes.RawBlockResponse{GasUsed: "0x5208"}),
+		// 30 gwei * 15,000,000 gas = 0.45 ether.
+		rpctypes.NewPolyBlock(&rpctypes.RawBlockResponse{GasUsed: "0xe4e1c0", BaseFeePerGas: "0x6fc23ac00"}),
+		// 1 ether * 2 gas.
+		rpctypes.NewPolyBlock(&rpctypes.RawBlockResponse{GasUsed: "0x2", BaseFeePerGas: "0xde0b6b3a7640000"}),
+	}
+
+	wei, ether := TotalBurned(blocks)
+	if wei.String() != "2450000000000000000" || ether != "2.45" {
+		t.Errorf("expected 2450000000000000000 wei or 2.45 ether burned, got %s wei or %s ether", wei, ether)
+	}
+
+	wei, ether = TotalBurned(blocks[:1])
+	if wei.Sign() != 0 || ether != "0" {
+		t.Errorf("expected nothing burned before London, got %s wei or %s ether", wei, ether)
+	}
+	if wei, _ = TotalBurned(nil); wei.Sign() != 0 {
+		t.Errorf("expected nothing burned for an empty range, got %s wei", wei)
+	}
+}
+
+func TestGetSelectorCounts(t *testing.T) {
+	to := rpctypes.RawData20Response("0x00000000000000000000000000000000000000aa")
+	blocks := []rpctypes.PolyBlock{
+		rpctypes.NewPolyBlock(&rpctypes.RawBlockResponse{Transactions: []rpctypes.RawTransactionResponse{
+			{To: to, Input: "0xa9059cbb01"},
+			{To: to, Input: "0x"},
+		}}),
+		rpctypes.NewPolyBlock(&rpctypes.RawBlockResponse{Transactions: []rpctypes.RawTransactionResponse{
+			{To: to, Input: "0xa9059cbb02"},
+			{To: to, Input: "0x095ea7b3"},
+			{Input: "0x60806040"},
+		}}),
+	}
+
+	counts, none := GetSelectorCounts(blocks)
+	if none != 2 {
+		t.Errorf("expected 2 transactions without a selector, got %d", none)
+	}
+	if c := counts[[4]byte{0xa9, 0x05, 0x9c, 0xbb}]; c != 2 {
+		t.Errorf("expected 2 transfer calls, got %d", c)
+	}
+	if c := counts[[4]byte{0x09, 0x5e, 0xa7, 0xb3}]; c != 1 {
+		t.Errorf("expected 1 approve call, got %d", c)
+	}
+}
+
+func TestGetSelectorReport(t *testing.T) {
+	to := rpctypes.RawData20Response("0x00000000000000000000000000000000000000aa")
+	txs := []rpctypes.RawTransactionResponse{
+		{To: to, Input: "0x095ea7b3"},
+		{To: to, Input: "0xa9059cbb"},
+		{To: to, Input: "0x"},
+		{To: to, Input: "0xa9059cbb"},
+		{To: to, Input: "0x23b872dd"},
+		{To: to, Input: "0xa9059cbb"},
+		{To: to, Input: "0x095ea7b3"},
+	}
+	blocks := []rpctypes.PolyBlock{rpctypes.NewPolyBlock(&rpctypes.RawBlockResponse{Transactions: txs})}
+	signatures := map[string]string{"0xa9059cbb": "transfer(address,uint256)"}
+
+	report, none := GetSelectorReport(blocks, signatures, 2)
+	if none != 1 {
+		t.Errorf("expected 1 value transfer, got %d", none)
+	}
+	expected := []SelectorCount{
+		{Selector: "0xa9059cbb", Signature: "transfer(address,uint256)", Count: 3},
+		{Selector: "0x095ea7b3", Count: 2},
+	}
+	if len(report) != len(expected) {
+		t.Fatalf("expected %d selectors, got %d", len(expected), len(report))
+	}
+	for idx := range expected {
+		if report[idx] != expected[idx] {
+			t.Errorf("expected %+v at position %d, got %+v", expected[idx], idx, report[idx])
+		}
+	}
+
+	if report, _ = GetSelectorReport(blocks, nil, 0); len(report) != 3 {
+		t.Errorf("expected all 3 selectors without a limit, got %d", len(report))
+	}
+}
+
+func TestGetNewSenders(t *testing.T) {
+	newBlock := func(senders ...string) rpctypes.PolyBlock {
+		txs := make([]rpctypes.RawTransactionResponse, len(senders))
+		for idx, s := range senders {
+			txs[idx].From = rpctypes.RawData20Response(s)
+		}
+		return rpctypes.NewPolyBlock(&rpctypes.RawBlockResponse{Transactions: txs})
+	}
+	a := "0x00000000000000000000000000000000000000aa"
+	b := "0x00000000000000000000000000000000000000bb"
+	c := "0x00000000000000000000000000000000000000cc"
+
+	seen := MapAccountSet{ethcommon.HexToAddress(a): {}}
+
+	senders := GetNewSenders(newBlock(a, b, b, c), seen)
+	if len(senders) != 2 || senders[0] != ethcommon.HexToAddress(b) || senders[1] != ethcommon.HexToAddress(c) {
+		t.Errorf("expected new senders %s and %s, got %v", b, c, senders)
+	}
+	if len(seen) != 3 {
+		t.Errorf("expected 3 seen accounts, got %d", len(seen))
+	}
+	if senders = GetNewSenders(newBlock(c, a), seen); len(senders) != 0 {
+		t.Errorf("expected no new senders, got %v", senders)
+	}
+}
+
+func TestGetBlockTimeJitter(t *testing.T) {
+	newBlocks := func(times ...uint64) []rpctypes.PolyBlock {
+		blocks := make([]rpctypes.PolyBlock, 0, len(times))
+		for i, ts := range times {
+			blocks = append(blocks, rpctypes.NewPolyBlock(&rpctypes.RawBlockResponse{
+				Number:    rpctypes.RawQuantityResponse(hexutil.EncodeUint64(uint64(i))),
+				Timestamp: rpctypes.RawQuantityResponse(hexutil.EncodeUint64(ts)),
+			}))
+		}
+		return blocks
+	}
+
+	regular := GetBlockTimeJitter(newBlocks(100, 102, 104, 106, 108), 2, 0.5)
+	if regular.StdDev != 0 || regular.OutsideTolerance != 0 {
+		t.Errorf("expected no jitter for regular blocks, got %+v", regular)
+	}
+
+	// The deltas are 2, 6, 2 and 2, so the mean is 3 and the variance is 3.
+	jittery := GetBlockTimeJitter(newBlocks(100, 102, 108, 110, 112), 2, 0.5)
+	if math.Abs(jittery.StdDev-math.Sqrt(3)) > 1e-9 || jittery.OutsideTolerance != 0.25 {
+		t.Errorf("expected a standard deviation of sqrt(3) and 0.25 outside tolerance, got %+v", jittery)
+	}
+
+	if jitter := GetBlockTimeJitter(newBlocks(100), 2, 0.5); jitter != (BlockTimeJitter{}) {
+		t.Errorf("expected no jitter for a single block, got %+v", jitter)
+	}
+}
+
+func TestIsPriceOrdered(t *testing.T) {
+	sender := rpctypes.RawData20Response("0x0000000000000000000000000000000000000001")
+	recipient := rpctypes.RawData20Response("0x0000000000000000000000000000000000000002")
+
+	type test struct {
+		name    string
+		block   *rpctypes.RawBlockResponse
+		ordered bool
+		index   int
+	}
+
+	tests := []test{{
+		name: "ordered",
+		block: &rpctypes.RawBlockResponse{
+			BaseFeePerGas: "0x14", // 20
+			Transactions: []rpctypes.RawTransactionResponse{
+				{From: sender, To: recipient, GasPrice: "0x28"}, // 40
+				// Effective gas price 20 + 10 = 30.
+				{From: sender, To: recipient, MaxFeePerGas: "0x64", MaxPriorityFeePerGas: "0xa"},
+				{From: sender, To: recipient, GasPrice: "0x1e"}, // 30
+			},
+		},
+		ordered: true,
+		index:   -1,
+	}, {
+		name: "unordered",
+		block: &rpctypes.RawBlockResponse{
+			BaseFeePerGas: "0x14", // 20
+			Transactions: []rpctypes.RawTransactionResponse{
+				{From: sender, To: recipient, GasPrice: "0x28"}, // 40
+				// Capped at the fee cap of 25.
+				{From: sender, To: recipient, MaxFeePerGas: "0x19", MaxPriorityFeePerGas: "0x64"},
+				{From: sender, To: recipient, GasPrice: "0x1e"}, // 30
+			},
+		},
+		ordered: false,
+		index:   2,
+	}, {
+		name: "state sync",
+		block: &rpctypes.RawBlockResponse{
+			Transactions: []rpctypes.RawTransactionResponse{
+				{GasPrice: "0x0"},
+				{From: sender, To: recipient, GasPrice: "0x28"},
+				{From: sender, To: recipient, GasPrice: "0x1e"},
+			},
+		},
+		ordered: true,
+		index:   -1,
+	}}
+
+	for _, tc := range tests {
+		ordered, index := IsPriceOrdered(rpctypes.NewPolyBlock(tc.block))
+		if ordered != tc.ordered || index != tc.index {
+			t.Errorf("%s: expected ordered %v at index %d, got %v at index %d", tc.name, tc.ordered, tc.index, ordered, index)
+		}
+	}
+}
+
+func TestReorgTracker(t *testing.T) {
+	// newBlock creates a block on a fork, where the fork only changes the hash
+	// of the blocks from the given height.
+	newBlock := func(number uint64, fork byte, forkedAt uint64) rpctypes.PolyBlock {
+		hashAt := func(n uint64) rpctypes.RawData32Response {
+			f := byte(0)
+			if n >= forkedAt {
+				f = fork
+			}
+			return rpctypes.RawData32Response(ethcommon.BytesToHash([]byte{f, byte(n)}).Hex())
+		}
+		return rpctypes.NewPolyBlock(&rpctypes.RawBlockResponse{
+			Number:     rpctypes.RawQuantityResponse(hexutil.EncodeUint64(number)),
+			Hash:       hashAt(number),
+			ParentHash: hashAt(number - 1),
+		})
+	}
+
+	tracker := NewReorgTracker(2)
+	for n := uint64(1); n <= 10; n++ {
+		if depth := tracker.Observe(newBlock(n, 0, 0)); depth != 0 {
+			t.Fatalf("expected no reorg at block %d, got depth %d", n, depth)
+		}
+	}
+	// Refetching a block that didn't change isn't a reorg.
+	if depth := tracker.Observe(newBlock(9, 0, 0)); depth != 0 {
+		t.Errorf("expected no reorg refetching a block, got depth %d", depth)
+	}
+
+	// A new head whose parent was replaced reorgs block 10.
+	if depth := tracker.Observe(newBlock(11, 1, 10)); depth != 1 {
+		t.Errorf("expected a reorg of depth 1, got %d", depth)
+	}
+	// Blocks 8 to 11 are replaced and refetched in order.
+	if depth := tracker.Observe(newBlock(8, 2, 8)); depth != 4 {
+		t.Errorf("expected a reorg of depth 4, got %d", depth)
+	}
+	for n := uint64(9); n <= 12; n++ {
+		if depth := tracker.Observe(newBlock(n, 2, 8)); depth != 0 {
+			t.Errorf("expected no reorg at block %d of the new fork, got depth %d", n, depth)
+		}
+	}
+	// Blocks 11 and 12 are replaced.
+	if depth := tracker.Observe(newBlock(11, 3, 11)); depth != 2 {
+		t.Errorf("expected a reorg of depth 2, got %d", depth)
+	}
+
+	stats := tracker.Stats()
+	if stats.Reorgs != 3 || stats.MaxDepth != 4 {
+		t.Errorf("expected 3 reorgs with a max depth of 4, got %s", stats)
+	}
+	if stats.Histogram[1] != 1 || stats.Histogram[2] != 1 || stats.Histogram[4] != 1 {
+		t.Errorf("unexpected histogram %v", stats.Histogram)
+	}
+
+	encoded, err := json.Marshal(tracker)
+	if err != nil {
+		t.Fatalf("could not encode tracker: %v", err)
+	}
+	if string(encoded) != `{"reorgs":3,"maxDepth":4,"histogram":{"1":1,"2":1,"4":1}}` {
+		t.Errorf("unexpected JSON %s", encoded)
+	}
+
+	tracker.Reset()
+	if stats = tracker.Stats(); stats.Reorgs != 0 || stats.MaxDepth != 0 || len(stats.Histogram) != 0 {
+		t.Errorf("expected no reorgs after a reset, got %s", stats)
+	}
+}
+
+func TestGetTopSpenders(t *testing.T) {
+	a := rpctypes.RawData20Response("0x000000000000000000000000000000000000000a")
+	b := rpctypes.RawData20Response("0x000000000000000000000000000000000000000b")
+	c := rpctypes.RawData20Response("0x000000000000000000000000000000000000000c")
+	miner := rpctypes.RawData20Response("0x00000000000000000000000000000000000000ff")
+
+	blocks := []rpctypes.PolyBlock{
+		rpctypes.NewPolyBlock(&rpctypes.RawBlockResponse{
+			Miner:         miner,
+			BaseFeePerGas: "0xa", // 10
+			Transactions: []rpctypes.RawTransactionResponse{
+				{Hash: "0x01", From: a, GasPrice: "0x14", Gas: "0x5208", Value: "0x5"},
+				// Without a receipt, the gas limit of 100000 is used.
+				{Hash: "0x02", From: a, GasPrice: "0x1e", Gas: "0x186a0"},
+				{Hash: "0x03", From: b, MaxFeePerGas: "0x64", MaxPriorityFeePerGas: "0x5", Gas: "0x186a0", Value: "0x1"},
+				{Hash: "0x04", From: c, GasPrice: "0x28", Gas: "0x5208"},
+				{Hash: "0x05", From: miner, GasPrice: "0x28", Gas: "0x5208"},
+				{Hash: "0x06", GasPrice: "0x0"},
+			},
+		}),
+		rpctypes.NewPolyBlock(&rpctypes.RawBlockResponse{
+			Miner:         a,
+			BaseFeePerGas: "0xa",
+			Transactions: []rpctypes.RawTransactionResponse{
+				{Hash: "0x07", From: b, GasPrice: "0x14", Gas: "0x5208"},
+			},
+		}),
+	}
+
+	receipt := func(hash rpctypes.RawData32Response, gasUsed, price rpctypes.RawQuantityResponse) rpctypes.PolyReceipt {
+		return rpctypes.NewPolyReceipt(&rpctypes.RawTxReceipt{TransactionHash: hash, GasUsed: gasUsed, EffectiveGasPrice: price})
+	}
+	receipts := rpctypes.PolyReceipts{
+		receipt("0x01", "0x5208", "0x14"),
+		receipt("0x03", "0xc350", "0xf"), // 50000 gas at 10 + 5
+		receipt("0x04", "0x5208", "0x28"),
+		receipt("0x05", "0x5208", "0x28"),
+		receipt("0x07", "0x5208", "0x14"),
+	}
+
+	type test struct {
+		sender       rpctypes.RawData20Response
+		transactions int
+		fees         int64
+		value        int64
+		estimated    bool
+	}
+	expected := []test{
+		{sender: a, transactions: 2, fees: 21000*20 + 100000*30, value: 5, estimated: true},
+		{sender: b, transactions: 2, fees: 50000*15 + 21000*20, value: 1},
+		{sender: c, transactions: 1, fees: 21000 * 40},
+	}
+
+	spenders := GetTopSpenders(blocks, receipts, 0)
+	if len(spenders) != len(expected) {
+		t.Fatalf("expected %d spenders, got %d", len(expected), len(spenders))
+	}
+	for idx, tc := range expected {
+		s := spenders[idx]
+		if s.Sender != tc.sender.ToAddress() || s.Transactions != tc.transactions || s.Fees.Int64() != tc.fees ||
+			s.Value.Int64() != tc.value || s.Estimated != tc.estimated {
+			t.Errorf("expected spender %d to be %+v, got %+v", idx, tc, s)
+		}
+	}
+
+	if top := GetTopSpenders(blocks, receipts, 1); len(top) != 1 || top[0].Sender != a.ToAddress() {
+		t.Errorf("expected only the top spender, got %+v", top)
+	}
+}
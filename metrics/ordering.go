@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	ethcommon "github.com/ethereum/go-ethereum/common"
+
+	"github.com/maticnetwork/polygon-cli/rpctypes"
+)
+
+// IsPriceOrdered reports whether the block's transactions are sorted by
+// effective gas price, highest first, which is how the default geth and bor
+// miners order them. Blocks built by builders or proposers with their own
+// ordering usually aren't. If the block isn't ordered, the index of the first
+// transaction priced higher than the one before it is also returned, otherwise
+// the index is -1.
+//
+// The effective gas prices are estimated with the block's base fee. Polygon's
+// state sync pseudo transactions, which are sent from and to the zero address
+// and don't pay for gas, are left out.
+func IsPriceOrdered(block rpctypes.PolyBlock) (bool, int) {
+	baseFee := block.BaseFee()
+
+	var prev rpctypes.PolyTransaction
+	for idx, tx := range block.Transactions() {
+		if tx.From() == (ethcommon.Address{}) && tx.To() == (ethcommon.Address{}) {
+			continue
+		}
+		if prev != nil {
+			price := rpctypes.EstimateEffectiveGasPrice(tx, baseFee, nil)
+			if price.Cmp(rpctypes.EstimateEffectiveGasPrice(prev, baseFee, nil)) > 0 {
+				return false, idx
+			}
+		}
+		prev = tx
+	}
+	return true, -1
+}
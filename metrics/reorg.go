@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+
+	"github.com/maticnetwork/polygon-cli/rpctypes"
+)
+
+// reorgHistory is how many of the latest block hashes the tracker remembers.
+// Reorgs deeper than this can't be detected.
+const reorgHistory = 256
+
+type (
+	// ReorgTracker detects reorgs in a stream of blocks and records their
+	// depth, which is the number of blocks that were replaced. The blocks
+	// should be observed in ascending order, and a block that was already
+	// observed can be observed again, e.g. when a range is refetched. It's
+	// safe for concurrent use.
+	ReorgTracker struct {
+		// WarnDepth is the depth above which a reorg is logged as a warning.
+		// Zero disables the warning.
+		WarnDepth uint64
+
+		mu        sync.Mutex
+		hashes    map[uint64]ethcommon.Hash
+		head      uint64
+		reorgs    int
+		maxDepth  uint64
+		histogram map[uint64]int
+	}
+
+	// ReorgStats summarizes the reorgs observed by a ReorgTracker.
+	ReorgStats struct {
+		Reorgs   int    `json:"reorgs"`
+		MaxDepth uint64 `json:"maxDepth"`
+		// Histogram counts the reorgs by their depth.
+		Histogram map[uint64]int `json:"histogram"`
+	}
+)
+
+// NewReorgTracker creates a tracker that warns about reorgs deeper than
+// warnDepth, or never if it's zero.
+func NewReorgTracker(warnDepth uint64) *ReorgTracker {
+	t := &ReorgTracker{WarnDepth: warnDepth}
+	t.reset()
+	return t
+}
+
+// Observe records the block and returns the depth of the reorg it reveals, or
+// zero if it doesn't reveal one. A reorg is revealed by a block whose hash
+// differs from the one already observed at its height, or whose parent hash
+// differs from the hash observed at the height below it. Every block observed
+// from the first differing height up to the highest block is considered
+// replaced.
+func (t *ReorgTracker) Observe(block rpctypes.PolyBlock) uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	number := block.Number().Uint64()
+	hash := block.Hash()
+
+	conflict, reorged := uint64(0), false
+	if prev, ok := t.hashes[number]; ok && prev != hash {
+		conflict, reorged = number, true
+	} else if number > 0 {
+		if parent, ok := t.hashes[number-1]; ok && parent != block.ParentHash() {
+			conflict, reorged = number-1, true
+		}
+	}
+
+	var depth uint64
+	if reorged {
+		depth = t.head - conflict + 1
+		for n := conflict; n <= t.head; n++ {
+			delete(t.hashes, n)
+		}
+		t.record(depth, number, hash)
+		// The block is the tip of the new fork.
+		t.head = number
+	} else if len(t.hashes) == 0 || number > t.head {
+		t.head = number
+	}
+	t.hashes[number] = hash
+
+	if len(t.hashes) > 2*reorgHistory {
+		for n := range t.hashes {
+			if n+reorgHistory < t.head {
+				delete(t.hashes, n)
+			}
+		}
+	}
+	return depth
+}
+
+func (t *ReorgTracker) record(depth, number uint64, hash ethcommon.Hash) {
+	t.reorgs++
+	t.histogram[depth]++
+	if depth > t.maxDepth {
+		t.maxDepth = depth
+	}
+
+	if t.WarnDepth > 0 && depth > t.WarnDepth {
+		log.Warn().
+			Uint64("depth", depth).
+			Uint64("threshold", t.WarnDepth).
+			Uint64("block", number).
+			Str("hash", hash.Hex()).
+			Msg("Reorg deeper than the threshold")
+	}
+}
+
+// Stats returns the reorgs observed since the tracker was created or reset.
+func (t *ReorgTracker) Stats() ReorgStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	histogram := make(map[uint64]int, len(t.histogram))
+	for depth, count := range t.histogram {
+		histogram[depth] = count
+	}
+	return ReorgStats{Reorgs: t.reorgs, MaxDepth: t.maxDepth, Histogram: histogram}
+}
+
+// MarshalJSON encodes the tracker's stats.
+func (t *ReorgTracker) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Stats())
+}
+
+// Reset forgets the observed blocks and reorgs.
+func (t *ReorgTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.reset()
+}
+
+func (t *ReorgTracker) reset() {
+	t.hashes = make(map[uint64]ethcommon.Hash)
+	t.head = 0
+	t.reorgs = 0
+	t.maxDepth = 0
+	t.histogram = make(map[uint64]int)
+}
+
+// String formats the stats for display.
+func (s ReorgStats) String() string {
+	return fmt.Sprintf("Reorgs: %d (max depth %d)", s.Reorgs, s.MaxDepth)
+}
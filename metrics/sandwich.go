@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	ethcommon "github.com/ethereum/go-ethereum/common"
+
+	"github.com/maticnetwork/polygon-cli/rpctypes"
+)
+
+// Sandwich is a candidate sandwich attack: a victim transaction with a
+// frontrun immediately before it and a backrun immediately after it.
+type Sandwich struct {
+	Frontrun rpctypes.PolyTransaction
+	Victim   rpctypes.PolyTransaction
+	Backrun  rpctypes.PolyTransaction
+	Target   ethcommon.Address
+}
+
+// FindSandwiches scans the block's transactions in order for the classic
+// sandwich pattern: three consecutive contract calls to the same address where
+// the first and last are from the same sender and the middle one isn't.
+//
+// This is only a heuristic based on the transactions' recipients. It reports
+// false positives, such as a bot that happens to trade the same pool on both
+// sides of an unrelated user, and it misses sandwiches where the attacker
+// calls its own contract rather than the victim's target, which is common, or
+// where other transactions sit between the legs.
+func FindSandwiches(block rpctypes.PolyBlock) []Sandwich {
+	txs := block.Transactions()
+	sandwiches := make([]Sandwich, 0)
+	for idx := 1; idx+1 < len(txs); idx++ {
+		front, victim, back := txs[idx-1], txs[idx], txs[idx+1]
+
+		target := victim.To()
+		if target == (ethcommon.Address{}) || len(victim.Data()) == 0 {
+			continue
+		}
+		if front.To() != target || back.To() != target || len(front.Data()) == 0 || len(back.Data()) == 0 {
+			continue
+		}
+		if front.From() != back.From() || front.From() == victim.From() {
+			continue
+		}
+
+		sandwiches = append(sandwiches, Sandwich{
+			Frontrun: front,
+			Victim:   victim,
+			Backrun:  back,
+			Target:   target,
+		})
+	}
+	return sandwiches
+}
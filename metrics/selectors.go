@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/maticnetwork/polygon-cli/rpctypes"
+)
+
+// GetSelectorCounts counts how often each method selector appears in the
+// transactions of the blocks. Transactions without a selector, i.e. value
+// transfers and contract creations, are counted separately.
+func GetSelectorCounts(blocks []rpctypes.PolyBlock) (map[[4]byte]int, int) {
+	counts := make(map[[4]byte]int)
+	none := 0
+	for _, b := range blocks {
+		for _, tx := range b.Transactions() {
+			selector, ok := tx.MethodSelector()
+			if !ok {
+				none++
+				continue
+			}
+			counts[selector]++
+		}
+	}
+	return counts, none
+}
+
+// SelectorCount is how often a method selector was called, with its function
+// signature if it's known.
+type SelectorCount struct {
+	Selector  string `json:"selector"`
+	Signature string `json:"signature,omitempty"`
+	Count     int    `json:"count"`
+}
+
+// GetSelectorReport ranks the method selectors called in the blocks by how
+// often they appear, most frequent first, and returns the top n, or all of
+// them if n isn't positive. Selectors are resolved to signatures with the
+// given map, keyed by 0x prefixed lowercase hex selectors, which may be nil.
+// The number of transactions without a selector is returned separately.
+func GetSelectorReport(blocks []rpctypes.PolyBlock, signatures map[string]string, n int) ([]SelectorCount, int) {
+	counts, none := GetSelectorCounts(blocks)
+
+	report := make([]SelectorCount, 0, len(counts))
+	for selector, count := range counts {
+		hexSelector := hexutil.Encode(selector[:])
+		report = append(report, SelectorCount{
+			Selector:  hexSelector,
+			Signature: signatures[hexSelector],
+			Count:     count,
+		})
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Count != report[j].Count {
+			return report[i].Count > report[j].Count
+		}
+		return report[i].Selector < report[j].Selector
+	})
+
+	if n > 0 && len(report) > n {
+		report = report[:n]
+	}
+	return report, none
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+
+	"github.com/maticnetwork/polygon-cli/rpctypes"
+)
+
+// SenderSpend is how much an account spent sending transactions.
+type SenderSpend struct {
+	Sender       ethcommon.Address `json:"sender"`
+	Transactions int               `json:"transactions"`
+	// Fees is the total paid for gas, in wei.
+	Fees *big.Int `json:"fees"`
+	// Value is the total value transferred, in wei.
+	Value *big.Int `json:"value"`
+	// Estimated is set if the fees of any of the sender's transactions were
+	// estimated because its receipt wasn't provided.
+	Estimated bool `json:"estimated"`
+}
+
+// GetTopSpenders sums the fees paid and the value transferred by each sender
+// of the blocks' transactions, and returns the senders ranked by the fees
+// they paid, highest first. Only the top n are returned, or all of them if n
+// isn't positive.
+//
+// The receipts are used for the exact gas used and effective gas price of each
+// transaction, and may be nil. Without a transaction's receipt, its gas price
+// is estimated with rpctypes.EstimateEffectiveGasPrice and its gas limit is
+// used instead of the gas used, which overestimates the fees. Transactions
+// sent by the block's own miner, and Polygon's state sync pseudo transactions
+// sent from the zero address, are left out.
+func GetTopSpenders(blocks []rpctypes.PolyBlock, receipts rpctypes.PolyReceipts, n int) []SenderSpend {
+	byHash := make(map[ethcommon.Hash]rpctypes.PolyReceipt, len(receipts))
+	for _, r := range receipts {
+		byHash[r.TransactionHash()] = r
+	}
+
+	spends := make(map[ethcommon.Address]*SenderSpend)
+	for _, b := range blocks {
+		baseFee := b.BaseFee()
+		miner := b.Miner()
+		for _, tx := range b.Transactions() {
+			sender := tx.From()
+			if sender == (ethcommon.Address{}) || sender == miner {
+				continue
+			}
+
+			spend, ok := spends[sender]
+			if !ok {
+				spend = &SenderSpend{Sender: sender, Fees: new(big.Int), Value: new(big.Int)}
+				spends[sender] = spend
+			}
+
+			receipt, ok := byHash[tx.Hash()]
+			var gas *big.Int
+			if ok {
+				gas = receipt.GasUsed()
+			} else {
+				gas = new(big.Int).SetUint64(tx.Gas())
+				spend.Estimated = true
+			}
+			price := rpctypes.EstimateEffectiveGasPrice(tx, baseFee, receipt)
+
+			spend.Transactions++
+			spend.Fees.Add(spend.Fees, new(big.Int).Mul(price, gas))
+			spend.Value.Add(spend.Value, tx.Value())
+		}
+	}
+
+	ranked := make([]SenderSpend, 0, len(spends))
+	for _, spend := range spends {
+		ranked = append(ranked, *spend)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if c := ranked[i].Fees.Cmp(ranked[j].Fees); c != 0 {
+			return c > 0
+		}
+		return bytes.Compare(ranked[i].Sender.Bytes(), ranked[j].Sender.Bytes()) < 0
+	})
+
+	if n > 0 && len(ranked) > n {
+		ranked = ranked[:n]
+	}
+	return ranked
+}
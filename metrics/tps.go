@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+type (
+	// TPSMeter measures transactions per second over a sliding wall-clock
+	// window. Windowing by time rather than by block count keeps the reading
+	// smooth when block times are bursty. It's safe for concurrent use.
+	TPSMeter struct {
+		window  time.Duration
+		mu      sync.Mutex
+		samples []tpsSample
+		head    uint64
+		hasHead bool
+	}
+	tpsSample struct {
+		at  time.Time
+		txs int
+	}
+)
+
+// NewTPSMeter creates a meter that averages over the given window.
+func NewTPSMeter(window time.Duration) *TPSMeter {
+	return &TPSMeter{window: window}
+}
+
+// Observe records a block with the given number of transactions at the given
+// time, which should be when the block arrived so it's comparable with the
+// wall-clock window.
+func (m *TPSMeter) Observe(at time.Time, txs int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.samples = append(m.samples, tpsSample{at: at, txs: txs})
+}
+
+// ObserveHead records the block with the given number as a new head that
+// arrived at the given time. Each block number is only counted once, so blocks
+// at or below the highest one already observed, such as re-fetched blocks or
+// a lagging node's head, are ignored. It returns whether the block was counted.
+func (m *TPSMeter) ObserveHead(number uint64, at time.Time, txs int) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.hasHead && number <= m.head {
+		return false
+	}
+	m.head, m.hasHead = number, true
+	m.samples = append(m.samples, tpsSample{at: at, txs: txs})
+	return true
+}
+
+// Rate returns the transactions per second over the window ending at now.
+// Samples that fall out of the window are discarded.
+func (m *TPSMeter) Rate(now time.Time) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	start := now.Add(-m.window)
+	kept := m.samples[:0]
+	total := 0
+	for _, s := range m.samples {
+		if !s.at.After(start) {
+			continue
+		}
+		kept = append(kept, s)
+		if !s.at.After(now) {
+			total += s.txs
+		}
+	}
+	m.samples = kept
+
+	return float64(total) / m.window.Seconds()
+}
+
+// Current returns the transactions per second over the window ending now.
+func (m *TPSMeter) Current() float64 {
+	return m.Rate(time.Now())
+}
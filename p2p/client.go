@@ -0,0 +1,53 @@
+package p2p
+
+import "strings"
+
+// UnknownClient is the client of names that can't be parsed.
+const UnknownClient = "unknown"
+
+// ClientInfo is a client name, as sent in the Hello message, split into its
+// parts. Names have the form client/version/os/runtime, e.g.
+// Geth/v1.13.5-stable/linux-amd64/go1.21.4, optionally with the node's
+// identity after the client, e.g. Geth/mynode/v1.13.5/linux-amd64/go1.21.4.
+type ClientInfo struct {
+	Client    string `json:"client"`
+	Version   string `json:"version,omitempty"`
+	OS        string `json:"os,omitempty"`
+	GoVersion string `json:"goVersion,omitempty"`
+}
+
+// ParseClientName parses the client name. The client is lowercased so the
+// names of a client family can be grouped. Names without a version aren't in
+// the usual form, so their client is UnknownClient. The Go version is only
+// set for clients written in Go.
+func ParseClientName(name string) ClientInfo {
+	parts := strings.Split(strings.TrimSpace(name), "/")
+	if len(parts) < 2 || parts[0] == "" {
+		return ClientInfo{Client: UnknownClient}
+	}
+
+	// The version is the first part after the client that looks like v1.2.3,
+	// since the identity can come before it.
+	version := -1
+	for idx, part := range parts[1:] {
+		if len(part) > 1 && (part[0] == 'v' || part[0] == 'V') && part[1] >= '0' && part[1] <= '9' {
+			version = idx + 1
+			break
+		}
+	}
+	if version < 0 {
+		return ClientInfo{Client: UnknownClient}
+	}
+
+	info := ClientInfo{
+		Client:  strings.ToLower(parts[0]),
+		Version: parts[version],
+	}
+	if len(parts) > version+1 {
+		info.OS = parts[version+1]
+	}
+	if len(parts) > version+2 && strings.HasPrefix(parts[version+2], "go") {
+		info.GoVersion = parts[version+2]
+	}
+	return info
+}
@@ -0,0 +1,28 @@
+package p2p
+
+import "testing"
+
+func TestParseClientName(t *testing.T) {
+	type test struct {
+		name     string
+		expected ClientInfo
+	}
+
+	tests := []test{
+		{name: "Geth/v1.13.5-stable-916d6a44/linux-amd64/go1.21.4", expected: ClientInfo{Client: "geth", Version: "v1.13.5-stable-916d6a44", OS: "linux-amd64", GoVersion: "go1.21.4"}},
+		{name: "bor/v1.2.0/linux-amd64/go1.21", expected: ClientInfo{Client: "bor", Version: "v1.2.0", OS: "linux-amd64", GoVersion: "go1.21"}},
+		{name: "Geth/mynode/v1.13.5/linux-amd64/go1.21.4", expected: ClientInfo{Client: "geth", Version: "v1.13.5", OS: "linux-amd64", GoVersion: "go1.21.4"}},
+		{name: "Nethermind/v1.22.0+a8f2b1c3/linux-x64/dotnet7.0.13", expected: ClientInfo{Client: "nethermind", Version: "v1.22.0+a8f2b1c3", OS: "linux-x64"}},
+		{name: "erigon/v2.55.1", expected: ClientInfo{Client: "erigon", Version: "v2.55.1"}},
+		{name: "my custom client", expected: ClientInfo{Client: UnknownClient}},
+		{name: "Geth/mynode/linux-amd64", expected: ClientInfo{Client: UnknownClient}},
+		{name: "/v1.0.0", expected: ClientInfo{Client: UnknownClient}},
+		{name: "", expected: ClientInfo{Client: UnknownClient}},
+	}
+
+	for _, tc := range tests {
+		if info := ParseClientName(tc.name); info != tc.expected {
+			t.Errorf("%q: expected %+v, got %+v", tc.name, tc.expected, info)
+		}
+	}
+}
@@ -1,49 +1,169 @@
 package p2p
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
-	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/rs/zerolog/log"
 )
 
 const jsonIndent = "    "
 
+var gzipMagic = []byte{0x1f, 0x8b}
+
 // NodeSet is the mapping of the node ID to the URL. This is used in the p2p
 // ping, crawl, and sensor commands. When written this should be consistent with
 // the geth/bor static-nodes.json file format which is just an JSON string array
 // of URLs.
 type NodeSet map[enode.ID]string
 
-// ReadNodeSet parses a list of discovery node URLs loaded from a JSON file.
+// NodeTags is the optional operator supplied metadata of a node, such as its
+// region or operator.
+type NodeTags map[string]string
+
+// taggedNode is the object form of a node set entry.
+type taggedNode struct {
+	URL  string   `json:"url"`
+	Tags NodeTags `json:"tags"`
+}
+
+// ReadNodeSet parses a list of discovery node URLs loaded from a JSON file. The
+// file can optionally be gzip compressed, which is detected by either a .gz
+// extension or the gzip magic bytes.
 func ReadNodeSet(file string) ([]*enode.Node, error) {
+	nodes, _, err := ReadTaggedNodeSet(file)
+	return nodes, err
+}
+
+// ReadTaggedNodeSet parses a node set like ReadNodeSet, but entries can also be
+// objects of the form {"url": "enode://...", "tags": {"region": "eu"}}. The
+// tags are returned keyed by the node ID. Plain URL entries have no tags.
+func ReadTaggedNodeSet(file string) ([]*enode.Node, map[enode.ID]NodeTags, error) {
 	// Load the nodes from the config file.
-	var nodelist []string
-	if err := common.LoadJSON(file, &nodelist); err != nil {
-		return nil, fmt.Errorf("failed to load node list file: %w", err)
+	data, err := readFile(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load node list file: %w", err)
+	}
+
+	var entries []json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, nil, fmt.Errorf("failed to load node list file: %w", err)
 	}
 
 	// Interpret the list as a discovery node array
 	var nodes []*enode.Node
-	for _, url := range nodelist {
-		if url == "" {
+	tags := make(map[enode.ID]NodeTags)
+	for _, entry := range entries {
+		node, nodeTags, ok := parseNodeSetEntry(entry)
+		if !ok {
 			continue
 		}
-		node, err := enode.Parse(enode.ValidSchemes, url)
+		nodes = append(nodes, node)
+		if len(nodeTags) > 0 {
+			tags[node.ID()] = nodeTags
+		}
+	}
+
+	return nodes, tags, nil
+}
+
+// ReadNodeSetStream parses a node set like ReadTaggedNodeSet, but decodes the
+// file incrementally and sends each node on the returned channel as soon as
+// it's parsed, so memory use doesn't grow with the size of the file. The tags
+// of object entries are ignored. The node channel is closed when the file has
+// been read or the context is done, after any error, including the context's,
+// is sent on the error channel, which is closed too. Either the node channel
+// must be drained or the context canceled for the file to be closed.
+func ReadNodeSetStream(ctx context.Context, file string) (<-chan *enode.Node, <-chan error) {
+	nodes := make(chan *enode.Node, 64)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(nodes)
+		if err := streamNodeSet(ctx, file, nodes); err != nil {
+			errs <- fmt.Errorf("failed to load node list file: %w", err)
+		}
+	}()
+
+	return nodes, errs
+}
+
+func streamNodeSet(ctx context.Context, file string, nodes chan<- *enode.Node) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	var r io.Reader = br
+	if magic, _ := br.Peek(len(gzipMagic)); strings.HasSuffix(file, ".gz") || bytes.Equal(magic, gzipMagic) {
+		gz, err := gzip.NewReader(r)
 		if err != nil {
-			log.Warn().Err(err).Str("url", url).Msg("Failed to parse enode")
-			continue
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	decoder := json.NewDecoder(r)
+	if tok, err := decoder.Token(); err != nil {
+		return err
+	} else if tok != json.Delim('[') {
+		return fmt.Errorf("expected a JSON array, got %v", tok)
+	}
+
+	for decoder.More() {
+		var entry json.RawMessage
+		if err := decoder.Decode(&entry); err != nil {
+			return err
+		}
+		if node, _, ok := parseNodeSetEntry(entry); ok {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case nodes <- node:
+			}
 		}
-		nodes = append(nodes, node)
 	}
 
-	return nodes, nil
+	_, err = decoder.Token()
+	return err
 }
 
-// WriteNodeSet writes the node set as a JSON list of URLs to a file.
+// parseNodeSetEntry parses a node set entry, which is either a URL or a
+// taggedNode object. Entries that can't be parsed are logged and skipped.
+func parseNodeSetEntry(entry json.RawMessage) (*enode.Node, NodeTags, bool) {
+	var tn taggedNode
+	if err := json.Unmarshal(entry, &tn.URL); err != nil {
+		if err := json.Unmarshal(entry, &tn); err != nil {
+			log.Warn().Err(err).RawJSON("entry", entry).Msg("Failed to parse node set entry")
+			return nil, nil, false
+		}
+	}
+
+	if tn.URL == "" {
+		return nil, nil, false
+	}
+	node, err := enode.Parse(enode.ValidSchemes, tn.URL)
+	if err != nil {
+		log.Warn().Err(err).Str("url", tn.URL).Msg("Failed to parse enode")
+		return nil, nil, false
+	}
+	return node, tn.Tags, true
+}
+
+// WriteNodeSet writes the node set as a JSON list of URLs to a file. If the file
+// has a .gz extension, the output will be gzip compressed.
 func WriteNodeSet(file string, nodes NodeSet) error {
 	urls := make([]string, 0, len(nodes))
 	for _, url := range nodes {
@@ -58,5 +178,45 @@ func WriteNodeSet(file string, nodes NodeSet) error {
 		_, err = os.Stdout.Write(bytes)
 		return err
 	}
-	return os.WriteFile(file, bytes, 0644)
+	return writeFile(file, bytes)
+}
+
+// readFile reads the whole file, transparently decompressing it if it's gzip
+// compressed.
+func readFile(file string) ([]byte, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasSuffix(file, ".gz") && !bytes.HasPrefix(data, gzipMagic) {
+		return data, nil
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	return io.ReadAll(gz)
+}
+
+// writeFile writes the data to the file, gzip compressing it if the file has a
+// .gz extension.
+func writeFile(file string, data []byte) error {
+	if !strings.HasSuffix(file, ".gz") {
+		return os.WriteFile(file, data, 0644)
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	return os.WriteFile(file, buf.Bytes(), 0644)
 }
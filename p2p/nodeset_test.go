@@ -0,0 +1,243 @@
+package p2p
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+func newTestNodeSet(t *testing.T, n int) NodeSet {
+	t.Helper()
+	nodes := make(NodeSet)
+	for i := 0; i < n; i++ {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatalf("could not generate key: %v", err)
+		}
+		node := enode.NewV4(&key.PublicKey, net.IPv4(10, 0, 0, byte(i+1)), 30303, 30303)
+		nodes[node.ID()] = node.URLv4()
+	}
+	return nodes
+}
+
+func TestNodeSetRoundTrip(t *testing.T) {
+	nodes := newTestNodeSet(t, 5)
+	dir := t.TempDir()
+
+	for _, name := range []string{"nodes.json", "nodes.json.gz"} {
+		file := filepath.Join(dir, name)
+		if err := WriteNodeSet(file, nodes); err != nil {
+			t.Fatalf("%s: could not write node set: %v", name, err)
+		}
+
+		read, err := ReadNodeSet(file)
+		if err != nil {
+			t.Fatalf("%s: could not read node set: %v", name, err)
+		}
+		if len(read) != len(nodes) {
+			t.Fatalf("%s: expected %d nodes, got %d", name, len(nodes), len(read))
+		}
+		for _, node := range read {
+			if nodes[node.ID()] != node.URLv4() {
+				t.Errorf("%s: unexpected node %s", name, node.URLv4())
+			}
+		}
+	}
+
+	// Compressed files without the .gz extension are detected by the magic
+	// bytes.
+	renamed := filepath.Join(dir, "compressed.json")
+	if err := os.Rename(filepath.Join(dir, "nodes.json.gz"), renamed); err != nil {
+		t.Fatalf("could not rename node set: %v", err)
+	}
+	read, err := ReadNodeSet(renamed)
+	if err != nil {
+		t.Fatalf("could not read renamed node set: %v", err)
+	}
+	if len(read) != len(nodes) {
+		t.Errorf("expected %d nodes, got %d", len(nodes), len(read))
+	}
+}
+
+func TestReadTaggedNodeSet(t *testing.T) {
+	nodes := newTestNodeSet(t, 2)
+	urls := make([]string, 0, len(nodes))
+	for _, url := range nodes {
+		urls = append(urls, url)
+	}
+
+	input := fmt.Sprintf(`[
+		%q,
+		{"url": %q, "tags": {"region": "eu", "operator": "acme"}}
+	]`, urls[0], urls[1])
+
+	file := filepath.Join(t.TempDir(), "tagged.json")
+	if err := os.WriteFile(file, []byte(input), 0644); err != nil {
+		t.Fatalf("could not write node set: %v", err)
+	}
+
+	read, tags, err := ReadTaggedNodeSet(file)
+	if err != nil {
+		t.Fatalf("could not read node set: %v", err)
+	}
+	if len(read) != 2 {
+		t.Fatalf("expected 2 nodes, got %d", len(read))
+	}
+	if len(tags) != 1 {
+		t.Fatalf("expected tags for 1 node, got %d", len(tags))
+	}
+
+	tagged := enode.MustParse(urls[1])
+	if tags[tagged.ID()]["region"] != "eu" || tags[tagged.ID()]["operator"] != "acme" {
+		t.Errorf("unexpected tags: %v", tags[tagged.ID()])
+	}
+	if _, ok := tags[enode.MustParse(urls[0]).ID()]; ok {
+		t.Errorf("expected no tags for a plain URL entry")
+	}
+}
+
+// streamNodes is the size of the node set TestReadNodeSetStream reads. Parsing
+// a million nodes takes about a minute, so -short and the race detector read
+// fewer.
+var streamNodes = flag.Int("stream-nodes", 1000000, "number of nodes in the streamed node set")
+
+// raceEnabled is set when the tests are built with the race detector.
+var raceEnabled bool
+
+// maxStreamHeapGrowth is how much the live heap may grow while streaming a
+// node set, whatever its size. It covers the buffered nodes and the decoder,
+// but not a reader that holds on to the nodes or the file.
+const maxStreamHeapGrowth = 4 << 20
+
+func TestReadNodeSetStream(t *testing.T) {
+	n := *streamNodes
+	explicit := false
+	flag.Visit(func(f *flag.Flag) { explicit = explicit || f.Name == "stream-nodes" })
+	if (testing.Short() || raceEnabled) && !explicit {
+		n = 50000
+	}
+
+	// Write a synthetic node set that repeats a few nodes, without holding it
+	// in memory.
+	urls := make([]string, 0, 4)
+	for _, url := range newTestNodeSet(t, 4) {
+		urls = append(urls, url)
+	}
+	file := filepath.Join(t.TempDir(), "nodes.json")
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatalf("could not create node set: %v", err)
+	}
+	w := bufio.NewWriter(f)
+	fmt.Fprint(w, "[")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			fmt.Fprint(w, ",\n")
+		}
+		if i%2 == 0 {
+			fmt.Fprintf(w, "%q", urls[i%len(urls)])
+		} else {
+			fmt.Fprintf(w, `{"url": %q, "tags": {"region": "eu"}}`, urls[i%len(urls)])
+		}
+	}
+	fmt.Fprint(w, "]")
+	if err = w.Flush(); err != nil {
+		t.Fatalf("could not write node set: %v", err)
+	}
+	f.Close()
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatalf("could not stat node set: %v", err)
+	}
+
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	baseline, peak := stats.HeapAlloc, stats.HeapAlloc
+
+	nodes, errs := ReadNodeSetStream(context.Background(), file)
+	read := 0
+	for range nodes {
+		read++
+		if read%10000 == 0 {
+			// Collect first so only the live heap is measured.
+			runtime.GC()
+			runtime.ReadMemStats(&stats)
+			if stats.HeapAlloc > peak {
+				peak = stats.HeapAlloc
+			}
+		}
+	}
+	if err = <-errs; err != nil {
+		t.Fatalf("could not read node set: %v", err)
+	}
+	if read != n {
+		t.Errorf("expected %d nodes, got %d", n, read)
+	}
+
+	// The live heap should stay flat, rather than grow with the file, which
+	// loading it whole would need.
+	growth := peak - baseline
+	t.Logf("the heap grew by %d bytes for a %d byte file", growth, info.Size())
+	if growth > maxStreamHeapGrowth {
+		t.Errorf("expected flat memory use, the heap grew by %d bytes for a %d byte file", growth, info.Size())
+	}
+
+	// Malformed files are reported on the error channel.
+	if err = os.WriteFile(file, []byte(`{"not": "a list"}`), 0644); err != nil {
+		t.Fatalf("could not write node set: %v", err)
+	}
+	nodes, errs = ReadNodeSetStream(context.Background(), file)
+	for range nodes {
+		t.Errorf("expected no nodes from a malformed file")
+	}
+	if err = <-errs; err == nil {
+		t.Errorf("expected an error for a malformed file")
+	}
+}
+
+func TestReadNodeSetStreamCancel(t *testing.T) {
+	// More nodes than the channel buffers, so the reader has to block.
+	var urls []string
+	for len(urls) < 200 {
+		for _, url := range newTestNodeSet(t, 4) {
+			urls = append(urls, url)
+		}
+	}
+	data, err := json.Marshal(urls)
+	if err != nil {
+		t.Fatalf("could not encode node set: %v", err)
+	}
+	file := filepath.Join(t.TempDir(), "nodes.json")
+	if err = os.WriteFile(file, data, 0644); err != nil {
+		t.Fatalf("could not write node set: %v", err)
+	}
+
+	// Canceling without draining the nodes stops the reader instead of leaving
+	// it blocked with the file open.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	nodes, errs := ReadNodeSetStream(ctx, file)
+	select {
+	case err = <-errs:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the reader to stop when the context is canceled")
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the context's error, got %v", err)
+	}
+	for range nodes {
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"net"
 	"strings"
 
+	"github.com/ethereum/go-ethereum/core/forkid"
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/p2p/enr"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -86,6 +87,26 @@ func ParseNode(source string) (*enode.Node, error) {
 	return enode.New(enode.ValidSchemes, r)
 }
 
+// ENREntry is the "eth" entry of a node record, which has the fork ID of the
+// chain the node is on.
+type ENREntry struct {
+	ForkID forkid.ID
+	Rest   []rlp.RawValue `rlp:"tail"`
+}
+
+// ENRKey implements enr.Entry.
+func (ENREntry) ENRKey() string { return "eth" }
+
+// NodeForkID returns the fork ID in the node's record, or false if the record
+// doesn't have an eth entry, e.g. for nodes given as enode URLs.
+func NodeForkID(n *enode.Node) (forkid.ID, bool) {
+	var entry ENREntry
+	if err := n.Load(&entry); err != nil {
+		return forkid.ID{}, false
+	}
+	return entry.ForkID, true
+}
+
 // ParseBootnodes parses the bootnodes string and returns a node slice.
 func ParseBootnodes(bootnodes string) ([]*enode.Node, error) {
 	s := strings.Split(bootnodes, ",")
@@ -0,0 +1,8 @@
+//go:build race
+
+package p2p
+
+func init() {
+	// The race detector makes parsing several times slower.
+	raceEnabled = true
+}
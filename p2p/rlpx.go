@@ -1,6 +1,7 @@
 package p2p
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"net"
@@ -15,16 +16,42 @@ import (
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/p2p/rlpx"
 	"github.com/rs/zerolog/log"
+	"golang.org/x/exp/slices"
 )
 
 var (
 	timeout = 20 * time.Second
+
+	// snapProbeTimeout bounds how long ProbeSnap waits for the peer.
+	snapProbeTimeout = 10 * time.Second
+
+	// snapProbeBytes is the soft limit on the size of the account range
+	// requested by ProbeSnap, which keeps the response small.
+	snapProbeBytes uint64 = 4096
 )
 
 // Dial attempts to Dial the given node and perform a handshake,
 // returning the created Conn if successful.
 func Dial(n *enode.Node) (*rlpxConn, error) {
-	fd, err := net.Dial("tcp", fmt.Sprintf("%v:%d", n.IP(), n.TCP()))
+	return DialContext(context.Background(), n)
+}
+
+// DialContext is like Dial, but the TCP connection is abandoned if the context
+// is done before it's established.
+func DialContext(ctx context.Context, n *enode.Node) (*rlpxConn, error) {
+	return DialContextVia(ctx, n, &net.Dialer{})
+}
+
+// ContextDialer opens the TCP connections RLPx sessions run over, such as a
+// net.Dialer or a SOCKS5 proxy's dialer.
+type ContextDialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// DialContextVia is like DialContext, but the TCP connection is opened with
+// the dialer, e.g. to go through a proxy.
+func DialContextVia(ctx context.Context, n *enode.Node, dialer ContextDialer) (*rlpxConn, error) {
+	fd, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(n.IP().String(), fmt.Sprint(n.TCP())))
 	if err != nil {
 		return nil, err
 	}
@@ -44,12 +71,20 @@ func Dial(n *enode.Node) (*rlpxConn, error) {
 		return nil, err
 	}
 
+	// Stop the handshake too if the context is done, since peers can accept
+	// the connection and then stall.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
 	defer func() { _ = conn.SetDeadline(time.Time{}) }()
 	if err = conn.SetDeadline(time.Now().Add(20 * time.Second)); err != nil {
 		return nil, err
 	}
 	if _, err = conn.Handshake(conn.ourKey); err != nil {
 		conn.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		return nil, err
 	}
 
@@ -59,17 +94,31 @@ func Dial(n *enode.Node) (*rlpxConn, error) {
 // Peer performs both the protocol handshake and the status message
 // exchange with the node in order to Peer with it.
 func (c *rlpxConn) Peer() (*Hello, *Status, error) {
-	hello, err := c.handshake()
+	hello, err := c.HelloExchange()
 	if err != nil {
-		return nil, nil, fmt.Errorf("handshake failed: %v", err)
+		return nil, nil, err
+	}
+	if err = c.CheckEthVersions(hello); err != nil {
+		return hello, nil, err
 	}
 	status, err := c.statusExchange()
 	if err != nil {
-		return hello, nil, fmt.Errorf("status exchange failed: %v", err)
+		return hello, nil, fmt.Errorf("status exchange failed: %w", err)
 	}
 	return hello, status, nil
 }
 
+// HelloExchange performs only the protocol handshake, exchanging Hello
+// messages with the node, without the eth status exchange that Peer also does.
+// This is enough to confirm the node is reachable and identify its client.
+func (c *rlpxConn) HelloExchange() (*Hello, error) {
+	hello, err := c.handshake()
+	if err != nil {
+		return nil, fmt.Errorf("handshake failed: %w", err)
+	}
+	return hello, nil
+}
+
 // handshake performs a protocol handshake with the node.
 func (c *rlpxConn) handshake() (*Hello, error) {
 	defer func() { _ = c.SetDeadline(time.Time{}) }()
@@ -95,10 +144,10 @@ func (c *rlpxConn) handshake() (*Hello, error) {
 			c.SetSnappy(true)
 		}
 		return msg, nil
-	case *Disconnect:
-		return nil, fmt.Errorf("disconnect received: %v", msg)
-	case *Disconnects:
-		return nil, fmt.Errorf("disconnect received: %v", msg)
+	case *Disconnect, *Disconnects:
+		return nil, disconnectError(msg)
+	case *Error:
+		return nil, fmt.Errorf("bad handshake: %w", msg)
 	default:
 		return nil, fmt.Errorf("bad handshake: %v", msg)
 	}
@@ -118,14 +167,14 @@ loop:
 		case *Status:
 			status = msg
 			break loop
-		case *Disconnect:
-			return nil, fmt.Errorf("disconnect received: %v", msg)
-		case *Disconnects:
-			return nil, fmt.Errorf("disconnect received: %v", msg)
+		case *Disconnect, *Disconnects:
+			return nil, disconnectError(msg)
 		case *Ping:
 			if err := c.Write(&Pong{}); err != nil {
 				c.logger.Error().Err(err).Msg("Write pong failed")
 			}
+		case *Error:
+			return nil, fmt.Errorf("bad status message: %w", msg)
 		default:
 			return nil, fmt.Errorf("bad status message: %v", msg)
 		}
@@ -138,13 +187,149 @@ loop:
 	return status, nil
 }
 
+// SetEthVersions replaces the eth protocol versions advertised in the Hello
+// message, by default eth/66 to eth/68, so the handshake only negotiates the
+// given versions. It has to be called before the handshake.
+func (c *rlpxConn) SetEthVersions(versions []uint) {
+	caps := make([]p2p.Cap, 0, len(c.caps)+len(versions))
+	for _, v := range versions {
+		caps = append(caps, p2p.Cap{Name: "eth", Version: v})
+	}
+	for _, cp := range c.caps {
+		if cp.Name != "eth" {
+			caps = append(caps, cp)
+		}
+	}
+	c.caps = caps
+}
+
+// CheckEthVersions returns an error if the peer's Hello doesn't advertise
+// any of the eth protocol versions we do, in which case the status can't be
+// exchanged.
+func (c *rlpxConn) CheckEthVersions(hello *Hello) error {
+	ours := make([]uint, 0, len(c.caps))
+	for _, cp := range c.caps {
+		if cp.Name == "eth" {
+			ours = append(ours, cp.Version)
+		}
+	}
+
+	theirs := make([]uint, 0, len(hello.Caps))
+	for _, cp := range hello.Caps {
+		if cp.Name != "eth" {
+			continue
+		}
+		if slices.Contains(ours, cp.Version) {
+			return nil
+		}
+		theirs = append(theirs, cp.Version)
+	}
+	return fmt.Errorf("no shared eth protocol version: requested %v, peer supports %v", ours, theirs)
+}
+
+// EnableSnap advertises snap/1 in the Hello message, which is needed to send
+// snap requests with ProbeSnap. It has to be called before the handshake.
+func (c *rlpxConn) EnableSnap() {
+	c.caps = append(c.caps, p2p.Cap{Name: "snap", Version: 1})
+}
+
+// ProbeSnap checks whether the peer actually serves snap sync data, rather
+// than only advertising snap/1. It fetches the header of the peer's head block
+// from the status and requests a small range of accounts from its state root.
+// The peer serves snap if it returns any accounts. Peers that don't advertise
+// snap/1 in their Hello aren't sent anything. EnableSnap must have been called
+// before the handshake.
+func (c *rlpxConn) ProbeSnap(hello *Hello, status *Status) (bool, error) {
+	if hello == nil || status == nil || !advertisesSnap(hello) {
+		return false, nil
+	}
+
+	defer func() { _ = c.SetDeadline(time.Time{}) }()
+	if err := c.SetDeadline(time.Now().Add(snapProbeTimeout)); err != nil {
+		return false, err
+	}
+
+	headersRequest := &GetBlockHeaders{
+		RequestId: rand.Uint64(),
+		GetBlockHeadersRequest: &eth.GetBlockHeadersRequest{
+			Origin: eth.HashOrNumber{Hash: status.Head},
+			Amount: 1,
+		},
+	}
+	if err := c.Write(headersRequest); err != nil {
+		return false, fmt.Errorf("write to connection failed: %v", err)
+	}
+	msg, err := c.readResponse(headersRequest.RequestId)
+	if err != nil {
+		return false, err
+	}
+	headers, ok := msg.(*BlockHeaders)
+	if !ok || len(headers.BlockHeadersRequest) == 0 {
+		return false, fmt.Errorf("peer didn't return the head block header")
+	}
+
+	rangeRequest := &GetAccountRange{
+		ID:     rand.Uint64(),
+		Root:   headers.BlockHeadersRequest[0].Root,
+		Origin: common.Hash{},
+		Limit:  common.MaxHash,
+		Bytes:  snapProbeBytes,
+	}
+	if err = c.Write(rangeRequest); err != nil {
+		return false, fmt.Errorf("write to connection failed: %v", err)
+	}
+	if msg, err = c.readResponse(rangeRequest.ID); err != nil {
+		return false, err
+	}
+	accounts, ok := msg.(*AccountRange)
+	if !ok {
+		return false, fmt.Errorf("unexpected response to the account range request: %v", msg)
+	}
+	return len(accounts.Accounts) > 0, nil
+}
+
+// readResponse reads messages until the response to the request with the
+// given id, answering pings and ignoring the messages the peer sends in the
+// meantime.
+func (c *rlpxConn) readResponse(id uint64) (Message, error) {
+	for {
+		switch msg := c.Read().(type) {
+		case *Ping:
+			if err := c.Write(&Pong{}); err != nil {
+				c.logger.Error().Err(err).Msg("Write pong failed")
+			}
+		case *Disconnect, *Disconnects:
+			return nil, disconnectError(msg)
+		case *Error:
+			if !strings.Contains(msg.Error(), "invalid message code") {
+				return nil, msg.Unwrap()
+			}
+		case *BlockHeaders, *AccountRange:
+			if msg.ReqID() == id {
+				return msg, nil
+			}
+		}
+	}
+}
+
+// advertisesSnap reports whether the Hello advertises snap/1.
+func advertisesSnap(hello *Hello) bool {
+	for _, c := range hello.Caps {
+		if c.Name == "snap" && c.Version == 1 {
+			return true
+		}
+	}
+	return false
+}
+
 // request stores the request ID and the block's hash.
 type request struct {
 	requestID uint64
 	hash      common.Hash
 }
 
-// ReadAndServe reads messages from peers and writes it to a database.
+// ReadAndServe reads messages from peers and writes it to a database. It
+// returns a *DisconnectError with the reason when the peer disconnects.
 func (c *rlpxConn) ReadAndServe(count *MessageCount) error {
 	for {
 		start := time.Now()
@@ -250,12 +435,11 @@ func (c *rlpxConn) ReadAndServe(count *MessageCount) error {
 				if !strings.Contains(msg.Error(), "timeout") {
 					return msg.Unwrap()
 				}
-			case *Disconnect:
-				atomic.AddInt32(&count.Disconnects, 1)
-				c.logger.Debug().Msgf("Disconnect received: %v", msg)
-			case *Disconnects:
+			case *Disconnect, *Disconnects:
 				atomic.AddInt32(&count.Disconnects, 1)
-				c.logger.Debug().Msgf("Disconnect received: %v", msg)
+				err := disconnectError(msg)
+				c.logger.Debug().Err(err).Msg("Disconnect received")
+				return err
 			default:
 				c.logger.Info().Interface("msg", msg).Int("code", msg.Code()).Msg("Received message")
 			}
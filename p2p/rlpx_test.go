@@ -0,0 +1,342 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/protocols/snap"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/rlpx"
+	"github.com/rs/zerolog/log"
+)
+
+// newTestConnPair returns a client connection, as returned by Dial, and the
+// server side of an in memory connection with the RLPx handshake done.
+func newTestConnPair(t *testing.T) (*rlpxConn, *rlpxConn) {
+	t.Helper()
+
+	clientKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("could not generate key: %v", err)
+	}
+	serverKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("could not generate key: %v", err)
+	}
+
+	fd0, fd1 := net.Pipe()
+	client := &rlpxConn{
+		Conn:   rlpx.NewConn(fd0, &serverKey.PublicKey),
+		ourKey: clientKey,
+		caps:   []p2p.Cap{{Name: "eth", Version: 68}},
+		logger: log.Logger,
+	}
+	server := &rlpxConn{
+		Conn:   rlpx.NewConn(fd1, nil),
+		ourKey: serverKey,
+		caps:   []p2p.Cap{{Name: "eth", Version: 68}},
+		logger: log.Logger,
+	}
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := server.Handshake(server.ourKey)
+		errc <- err
+	}()
+	if _, err = client.Handshake(client.ourKey); err != nil {
+		t.Fatalf("client handshake failed: %v", err)
+	}
+	if err = <-errc; err != nil {
+		t.Fatalf("server handshake failed: %v", err)
+	}
+
+	return client, server
+}
+
+func TestHelloExchange(t *testing.T) {
+	client, server := newTestConnPair(t)
+
+	// The mock peer answers the Hello but never sends its status.
+	go func() {
+		if _, ok := server.Read().(*Hello); !ok {
+			return
+		}
+		pub := crypto.FromECDSAPub(&server.ourKey.PublicKey)[1:]
+		_ = server.Write(&Hello{Version: 5, Name: "mock/v1.0.0", Caps: server.caps, ID: pub})
+	}()
+
+	done := make(chan struct{})
+	var (
+		hello *Hello
+		err   error
+	)
+	go func() {
+		hello, err = client.HelloExchange()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("hello exchange didn't return without a status")
+	}
+	if err != nil {
+		t.Fatalf("hello exchange failed: %v", err)
+	}
+	if hello.Name != "mock/v1.0.0" {
+		t.Errorf("expected client name mock/v1.0.0, got %s", hello.Name)
+	}
+}
+
+func TestProbeSnap(t *testing.T) {
+	root := common.HexToHash("0x01")
+	head := common.HexToHash("0x02")
+	status := &Status{Head: head}
+
+	type test struct {
+		name     string
+		caps     []p2p.Cap
+		accounts []*snap.AccountData
+		expected bool
+	}
+	tests := []test{
+		{
+			name:     "serving",
+			caps:     []p2p.Cap{{Name: "eth", Version: 68}, {Name: "snap", Version: 1}},
+			accounts: []*snap.AccountData{{Hash: common.HexToHash("0x03"), Body: []byte{0xc0}}},
+			expected: true,
+		},
+		{
+			name:     "not serving",
+			caps:     []p2p.Cap{{Name: "eth", Version: 68}, {Name: "snap", Version: 1}},
+			expected: false,
+		},
+		{
+			name:     "not advertised",
+			caps:     []p2p.Cap{{Name: "eth", Version: 68}},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client, server := newTestConnPair(t)
+			client.EnableSnap()
+
+			// The mock peer returns the head header and then the accounts from
+			// its state root.
+			errc := make(chan error, 1)
+			go func() {
+				headers, ok := server.Read().(*GetBlockHeaders)
+				if !ok || headers.Origin.Hash != head || headers.Amount != 1 {
+					errc <- fmt.Errorf("unexpected headers request %v", headers)
+					return
+				}
+				_ = server.Write(&BlockHeaders{
+					RequestId:           headers.RequestId,
+					BlockHeadersRequest: []*types.Header{{Root: root, Number: big.NewInt(1)}},
+				})
+
+				msg, err := server.ReadSnap(0)
+				if err != nil {
+					errc <- err
+					return
+				}
+				accounts, ok := msg.(*GetAccountRange)
+				if !ok || accounts.Root != root || accounts.Bytes > snapProbeBytes {
+					errc <- fmt.Errorf("unexpected account range request %v", msg)
+					return
+				}
+				errc <- server.Write(&AccountRange{ID: accounts.ID, Accounts: tc.accounts})
+			}()
+
+			serving, err := client.ProbeSnap(&Hello{Caps: tc.caps}, status)
+			if err != nil {
+				t.Fatalf("probe failed: %v", err)
+			}
+			if serving != tc.expected {
+				t.Errorf("expected serving to be %v, got %v", tc.expected, serving)
+			}
+			if advertisesSnap(&Hello{Caps: tc.caps}) {
+				if err = <-errc; err != nil {
+					t.Errorf("mock peer failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestPeerDisconnect(t *testing.T) {
+	client, server := newTestConnPair(t)
+
+	// The mock peer answers the Hello and then disconnects, as peers that are
+	// full do.
+	go func() {
+		if _, ok := server.Read().(*Hello); !ok {
+			return
+		}
+		pub := crypto.FromECDSAPub(&server.ourKey.PublicKey)[1:]
+		_ = server.Write(&Hello{Version: 5, Name: "mock/v1.0.0", Caps: server.caps, ID: pub})
+		server.SetSnappy(true)
+		_ = server.Write(&Disconnect{Reason: p2p.DiscTooManyPeers})
+	}()
+
+	hello, _, err := client.Peer()
+	if hello == nil {
+		t.Fatalf("expected the hello before the disconnect, got error %v", err)
+	}
+	var discErr *DisconnectError
+	if !errors.As(err, &discErr) {
+		t.Fatalf("expected a disconnect error, got %v", err)
+	}
+	if discErr.Reason != p2p.DiscTooManyPeers {
+		t.Errorf("expected reason %v, got %v", p2p.DiscTooManyPeers, discErr.Reason)
+	}
+}
+
+func TestReadAndServeDisconnect(t *testing.T) {
+	client, server := newTestConnPair(t)
+	client.SetSnappy(true)
+	server.SetSnappy(true)
+
+	go func() {
+		_ = server.Write(&Disconnect{Reason: p2p.DiscUselessPeer})
+	}()
+
+	count := &MessageCount{}
+	err := client.ReadAndServe(count)
+	var discErr *DisconnectError
+	if !errors.As(err, &discErr) {
+		t.Fatalf("expected a disconnect error, got %v", err)
+	}
+	if discErr.Reason != p2p.DiscUselessPeer {
+		t.Errorf("expected reason %v, got %v", p2p.DiscUselessPeer, discErr.Reason)
+	}
+	if count.Disconnects != 1 {
+		t.Errorf("expected 1 disconnect counted, got %d", count.Disconnects)
+	}
+}
+
+// countingDialer counts the connections it opens.
+type countingDialer struct {
+	net.Dialer
+	dials int32
+}
+
+func (d *countingDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	atomic.AddInt32(&d.dials, 1)
+	return d.Dialer.DialContext(ctx, network, address)
+}
+
+func TestDialContextVia(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("could not generate key: %v", err)
+	}
+
+	// The mock peer completes the RLPx handshake.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		c, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		_, _ = rlpx.NewConn(c, nil).Handshake(key)
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	node := enode.NewV4(&key.PublicKey, addr.IP, addr.Port, addr.Port)
+
+	dialer := &countingDialer{}
+	conn, err := DialContextVia(context.Background(), node, dialer)
+	if err != nil {
+		t.Fatalf("could not dial: %v", err)
+	}
+	conn.Close()
+	if dialer.dials != 1 {
+		t.Errorf("expected the connection to be opened with the dialer, got %d dials", dialer.dials)
+	}
+}
+
+func TestEthVersions(t *testing.T) {
+	conn := &rlpxConn{caps: []p2p.Cap{{Name: "eth", Version: 66}, {Name: "eth", Version: 67}, {Name: "eth", Version: 68}}}
+	conn.EnableSnap()
+	conn.SetEthVersions([]uint{67})
+
+	expected := []p2p.Cap{{Name: "eth", Version: 67}, {Name: "snap", Version: 1}}
+	if len(conn.caps) != len(expected) || conn.caps[0] != expected[0] || conn.caps[1] != expected[1] {
+		t.Fatalf("expected caps %v, got %v", expected, conn.caps)
+	}
+
+	type test struct {
+		name string
+		caps []p2p.Cap
+		fail bool
+	}
+	tests := []test{
+		{name: "shared", caps: []p2p.Cap{{Name: "eth", Version: 67}, {Name: "eth", Version: 68}}},
+		{name: "not shared", caps: []p2p.Cap{{Name: "eth", Version: 68}, {Name: "snap", Version: 1}}, fail: true},
+		{name: "no eth", caps: []p2p.Cap{{Name: "snap", Version: 1}}, fail: true},
+	}
+	for _, tc := range tests {
+		err := conn.CheckEthVersions(&Hello{Caps: tc.caps})
+		if (err != nil) != tc.fail {
+			t.Errorf("%s: expected failure %v, got error %v", tc.name, tc.fail, err)
+		}
+	}
+}
+
+func TestDialContextTimeout(t *testing.T) {
+	// The mock peer accepts the connection and never answers the handshake.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			c, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer c.Close()
+		}
+	}()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("could not generate key: %v", err)
+	}
+	addr := listener.Addr().(*net.TCPAddr)
+	node := enode.NewV4(&key.PublicKey, addr.IP, addr.Port, addr.Port)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err = DialContext(ctx, node); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the dial to time out, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the dial to stop at the timeout, took %s", elapsed)
+	}
+}
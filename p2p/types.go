@@ -3,6 +3,9 @@ package p2p
 import (
 	"crypto/ecdsa"
 	"fmt"
+	"math/big"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -35,6 +38,29 @@ func errorf(format string, args ...interface{}) *Error {
 	return &Error{fmt.Errorf(format, args...)}
 }
 
+// DisconnectError is returned when the peer disconnects, with the reason it
+// gave.
+type DisconnectError struct {
+	Reason p2p.DiscReason
+}
+
+func (e *DisconnectError) Error() string {
+	return fmt.Sprintf("disconnect received: %v", e.Reason)
+}
+
+// disconnectError returns the error for a Disconnect or Disconnects message.
+func disconnectError(msg Message) *DisconnectError {
+	switch msg := msg.(type) {
+	case *Disconnect:
+		return &DisconnectError{Reason: msg.Reason}
+	case *Disconnects:
+		if len(*msg) > 0 {
+			return &DisconnectError{Reason: (*msg)[0]}
+		}
+	}
+	return &DisconnectError{Reason: p2p.DiscRequested}
+}
+
 // Hello is the RLP structure of the protocol handshake.
 type Hello struct {
 	Version    uint64
@@ -50,6 +76,23 @@ type Hello struct {
 func (msg Hello) Code() int     { return 0x00 }
 func (msg Hello) ReqID() uint64 { return 0 }
 
+// Capabilities returns the capabilities the peer advertised as sorted and
+// deduplicated name/version strings, e.g. eth/68.
+func (msg *Hello) Capabilities() []string {
+	seen := make(map[string]struct{}, len(msg.Caps))
+	caps := make([]string, 0, len(msg.Caps))
+	for _, c := range msg.Caps {
+		cp := fmt.Sprintf("%s/%d", strings.ToLower(c.Name), c.Version)
+		if _, ok := seen[cp]; ok {
+			continue
+		}
+		seen[cp] = struct{}{}
+		caps = append(caps, cp)
+	}
+	sort.Strings(caps)
+	return caps
+}
+
 // Disconnect is the RLP structure for a disconnect message.
 type Disconnect struct {
 	Reason p2p.DiscReason
@@ -75,11 +118,61 @@ func (msg Pong) Code() int     { return 0x03 }
 func (msg Pong) ReqID() uint64 { return 0 }
 
 // Status is the network packet for the status message for eth/64 and later.
+// Its NetworkID and ForkID fields are already typed, so only the other fields
+// have accessors.
 type Status eth.StatusPacket
 
 func (msg Status) Code() int     { return 16 }
 func (msg Status) ReqID() uint64 { return 0 }
 
+// TotalDifficulty returns a copy of the total difficulty of the peer's chain,
+// or zero if the status didn't have one.
+func (msg Status) TotalDifficulty() *big.Int {
+	if msg.TD == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(msg.TD)
+}
+
+// BestHash returns the hash of the peer's head block.
+func (msg Status) BestHash() common.Hash { return msg.Head }
+
+// GenesisHash returns the hash of the peer's genesis block.
+func (msg Status) GenesisHash() common.Hash { return msg.Genesis }
+
+// FormatPeer renders the Hello and Status messages as a compact multi-line
+// string for terminal output. Either message can be nil if the handshake or
+// status exchange failed, in which case that section is noted as missing.
+func FormatPeer(hello *Hello, status *Status) string {
+	var b strings.Builder
+
+	if hello == nil {
+		b.WriteString("Hello:       <none>\n")
+	} else {
+		caps := make([]string, 0, len(hello.Caps))
+		for _, c := range hello.Caps {
+			caps = append(caps, c.String())
+		}
+
+		fmt.Fprintf(&b, "Client:      %s\n", hello.Name)
+		fmt.Fprintf(&b, "Caps:        %s\n", strings.Join(caps, ", "))
+		fmt.Fprintf(&b, "Listen Port: %d\n", hello.ListenPort)
+	}
+
+	if status == nil {
+		b.WriteString("Status:      <none>\n")
+	} else {
+		fmt.Fprintf(&b, "Network:     %d\n", status.NetworkID)
+		fmt.Fprintf(&b, "Protocol:    eth/%d\n", status.ProtocolVersion)
+		fmt.Fprintf(&b, "TD:          %v\n", status.TD)
+		fmt.Fprintf(&b, "Head:        %s\n", status.Head.Hex())
+		fmt.Fprintf(&b, "Genesis:     %s\n", status.Genesis.Hex())
+		fmt.Fprintf(&b, "Fork ID:     %#x (next %d)\n", status.ForkID.Hash, status.ForkID.Next)
+	}
+
+	return b.String()
+}
+
 // NewBlockHashes is the network packet for the block announcements.
 type NewBlockHashes eth.NewBlockHashesPacket
 
@@ -160,7 +253,7 @@ type rlpxConn struct {
 func (c *rlpxConn) Read() Message {
 	code, rawData, _, err := c.Conn.Read()
 	if err != nil {
-		return errorf("could not read from connection: %v", err)
+		return errorf("could not read from connection: %w", err)
 	}
 
 	var msg Message
@@ -223,6 +316,8 @@ func (c *rlpxConn) Read() Message {
 			return errorf("could not rlp decode message: %v", err)
 		}
 		return (*GetPooledTransactions)(ethMsg)
+	case (AccountRange{}).Code():
+		msg = new(AccountRange)
 	case (PooledTransactions{}.Code()):
 		ethMsg := new(eth.PooledTransactionsPacket)
 		if err := rlp.DecodeBytes(rawData, ethMsg); err != nil {
@@ -0,0 +1,35 @@
+package p2p
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestStatusAccessors(t *testing.T) {
+	status := Status{
+		NetworkID: 137,
+		TD:        big.NewInt(1000),
+		Head:      common.Hash{0xaa},
+		Genesis:   common.Hash{0xbb},
+	}
+
+	if status.BestHash() != (common.Hash{0xaa}) || status.GenesisHash() != (common.Hash{0xbb}) {
+		t.Errorf("unexpected hashes %s and %s", status.BestHash(), status.GenesisHash())
+	}
+
+	td := status.TotalDifficulty()
+	if td.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("expected a total difficulty of 1000, got %s", td)
+	}
+	// The total difficulty is a copy.
+	td.SetInt64(1)
+	if status.TD.Int64() != 1000 {
+		t.Errorf("expected the status to be unchanged, got %s", status.TD)
+	}
+
+	if td := (Status{}).TotalDifficulty(); td.Sign() != 0 {
+		t.Errorf("expected a missing total difficulty to be zero, got %s", td)
+	}
+}
@@ -0,0 +1,45 @@
+package rpctypes
+
+import (
+	"errors"
+	"math/big"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Bundle is a Flashbots style bundle, which is an ordered list of raw signed
+// transactions that should be included in the target block.
+type Bundle struct {
+	Txs         []string `json:"txs"`
+	BlockNumber string   `json:"blockNumber"`
+}
+
+// ToBundle re-encodes the transactions to their raw signed form and packages
+// them as a bundle targeting the given block number. Transactions that can't be
+// re-encoded, such as the Polygon state sync pseudo transactions, are left out
+// and their hashes returned, so the caller can decide whether the bundle still
+// has the transactions it needs.
+func ToBundle(txs PolyTransactions, blockNumber *big.Int) (*Bundle, []ethcommon.Hash, error) {
+	if blockNumber == nil {
+		return nil, nil, errors.New("bundle needs a target block number")
+	}
+
+	bundle := &Bundle{
+		Txs:         make([]string, 0, len(txs)),
+		BlockNumber: hexutil.EncodeBig(blockNumber),
+	}
+
+	var skipped []ethcommon.Hash
+	for _, tx := range txs {
+		raw, err := tx.RawBytes()
+		if err != nil {
+			skipped = append(skipped, tx.Hash())
+			continue
+		}
+
+		bundle.Txs = append(bundle.Txs, hexutil.Encode(raw))
+	}
+
+	return bundle, skipped, nil
+}
@@ -0,0 +1,99 @@
+package rpctypes
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+)
+
+func TestToBundle(t *testing.T) {
+	key, err := ethcrypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("could not generate key: %v", err)
+	}
+	signer := ethtypes.LatestSignerForChainID(big.NewInt(137))
+	to := ethcommon.HexToAddress("0xbb")
+
+	// sign returns the signed transaction as the node would return it, and its
+	// canonical encoding.
+	sign := func(data ethtypes.TxData) (PolyTransaction, string) {
+		signed, err := ethtypes.SignNewTx(key, signer, data)
+		if err != nil {
+			t.Fatalf("could not sign transaction: %v", err)
+		}
+		encoded, err := signed.MarshalJSON()
+		if err != nil {
+			t.Fatalf("could not encode transaction: %v", err)
+		}
+		raw := new(RawTransactionResponse)
+		if err = json.Unmarshal(encoded, raw); err != nil {
+			t.Fatalf("could not decode transaction: %v", err)
+		}
+		binary, err := signed.MarshalBinary()
+		if err != nil {
+			t.Fatalf("could not encode transaction: %v", err)
+		}
+		return NewPolyTransaction(raw), hexutil.Encode(binary)
+	}
+
+	legacy, legacyRaw := sign(&ethtypes.LegacyTx{Nonce: 1, GasPrice: big.NewInt(30), Gas: 21000, To: &to, Value: big.NewInt(1)})
+	blob, blobRaw := sign(&ethtypes.BlobTx{ChainID: uint256.NewInt(137), Nonce: 2, GasTipCap: uint256.NewInt(2), GasFeeCap: uint256.NewInt(40),
+		Gas: 21000, To: to, Value: uint256.NewInt(0), BlobFeeCap: uint256.NewInt(3), BlobHashes: []ethcommon.Hash{{0x01}}})
+	stateSync := NewPolyTransaction(&RawTransactionResponse{
+		Hash: "0x0000000000000000000000000000000000000000000000000000000000000001",
+		Type: "0x0",
+	})
+
+	type test struct {
+		name    string
+		txs     PolyTransactions
+		number  *big.Int
+		raw     []string
+		skipped []ethcommon.Hash
+		fail    bool
+	}
+
+	tests := []test{
+		{name: "signed", txs: PolyTransactions{legacy, blob}, number: big.NewInt(100), raw: []string{legacyRaw, blobRaw}},
+		{name: "state sync", txs: PolyTransactions{legacy, stateSync, blob}, number: big.NewInt(100),
+			raw: []string{legacyRaw, blobRaw}, skipped: []ethcommon.Hash{stateSync.Hash()}},
+		{name: "empty", txs: nil, number: big.NewInt(100), raw: []string{}},
+		{name: "no block number", txs: PolyTransactions{legacy}, fail: true},
+	}
+
+	for _, tc := range tests {
+		bundle, skipped, err := ToBundle(tc.txs, tc.number)
+		if tc.fail {
+			if err == nil {
+				t.Errorf("%s: expected an error", tc.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: could not build bundle: %v", tc.name, err)
+			continue
+		}
+
+		if bundle.BlockNumber != "0x64" {
+			t.Errorf("%s: expected block number 0x64, got %s", tc.name, bundle.BlockNumber)
+		}
+		if len(bundle.Txs) != len(tc.raw) {
+			t.Errorf("%s: expected %d transactions, got %d", tc.name, len(tc.raw), len(bundle.Txs))
+			continue
+		}
+		for idx := range tc.raw {
+			if bundle.Txs[idx] != tc.raw[idx] {
+				t.Errorf("%s: expected transaction %d to be %s, got %s", tc.name, idx, tc.raw[idx], bundle.Txs[idx])
+			}
+		}
+		if len(skipped) != len(tc.skipped) || len(skipped) > 0 && skipped[0] != tc.skipped[0] {
+			t.Errorf("%s: expected skipped %v, got %v", tc.name, tc.skipped, skipped)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package rpctypes
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/rs/zerolog/log"
+)
+
+// CanonicalJSON encodes the value as compact JSON with the object keys sorted
+// and 0x prefixed hex strings lowercased, so the same content always encodes to
+// the same bytes regardless of the key order or hex case of the source.
+func CanonicalJSON(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err = decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return json.Marshal(canonicalValue(value))
+}
+
+// canonicalValue lowercases the hex strings in the decoded JSON value. Maps
+// are encoded with sorted keys by encoding/json.
+func canonicalValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, elem := range v {
+			v[key] = canonicalValue(elem)
+		}
+	case []any:
+		for idx, elem := range v {
+			v[idx] = canonicalValue(elem)
+		}
+	case string:
+		if len(v) > 2 && (strings.HasPrefix(v, "0x") || strings.HasPrefix(v, "0X")) {
+			if _, err := ParseHexString(v); err == nil {
+				return strings.ToLower(v)
+			}
+		}
+	}
+	return value
+}
+
+// ContentHash returns the keccak256 hash of the block's canonical JSON, which
+// covers every field of the block and its transactions, so identical dumps of
+// a block have the same content hash. It's meant for deduplicating and
+// verifying stored dumps and is not the consensus block hash, which only
+// covers the header. Blocks fetched with and without full transactions have
+// different content hashes.
+func (i *implPolyBlock) ContentHash() ethcommon.Hash {
+	data, err := CanonicalJSON(i.inner)
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to encode block to compute its content hash")
+		return ethcommon.Hash{}
+	}
+	return crypto.Keccak256Hash(data)
+}
@@ -0,0 +1,61 @@
+package rpctypes
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestContentHash(t *testing.T) {
+	decode := func(data string) PolyBlock {
+		t.Helper()
+		var raw RawBlockResponse
+		if err := json.Unmarshal([]byte(data), &raw); err != nil {
+			t.Fatalf("could not decode block: %v", err)
+		}
+		return NewPolyBlock(&raw)
+	}
+
+	block := decode(`{
+		"number": "0x10",
+		"hash": "0x00000000000000000000000000000000000000000000000000000000000000ab",
+		"gasUsed": "0x5208",
+		"transactions": [{"hash": "0x01", "input": "0xA9059CBB", "value": "0x1"}]
+	}`)
+	hash := block.ContentHash()
+
+	// Marshaling and decoding the block again doesn't change its content hash.
+	for run := 0; run < 3; run++ {
+		encoded, err := json.Marshal(block)
+		if err != nil {
+			t.Fatalf("could not encode block: %v", err)
+		}
+		block = decode(string(encoded))
+		if block.ContentHash() != hash {
+			t.Fatalf("run %d: expected content hash %s, got %s", run, hash, block.ContentHash())
+		}
+	}
+
+	// Neither does the key order or the hex case of the source.
+	reordered := decode(`{
+		"transactions": [{"value": "0x1", "input": "0xa9059cbb", "hash": "0x01"}],
+		"gasUsed": "0x5208",
+		"hash": "0x00000000000000000000000000000000000000000000000000000000000000AB",
+		"number": "0x10"
+	}`)
+	if reordered.ContentHash() != hash {
+		t.Errorf("expected the reordered block to have content hash %s, got %s", hash, reordered.ContentHash())
+	}
+
+	changed := decode(`{
+		"number": "0x10",
+		"hash": "0x00000000000000000000000000000000000000000000000000000000000000ab",
+		"gasUsed": "0x5208",
+		"transactions": [{"hash": "0x01", "input": "0xa9059cbb", "value": "0x2"}]
+	}`)
+	if changed.ContentHash() == hash {
+		t.Errorf("expected a different content hash when a transaction changes")
+	}
+	if hash == block.Hash() {
+		t.Errorf("expected the content hash to differ from the block hash")
+	}
+}
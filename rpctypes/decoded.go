@@ -0,0 +1,107 @@
+package rpctypes
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// gweiQuantities are the keys of the per gas fees, which are decoded to gwei
+// rather than wei.
+var gweiQuantities = map[string]bool{
+	"baseFeePerGas":        true,
+	"gasPrice":             true,
+	"maxFeePerGas":         true,
+	"maxPriorityFeePerGas": true,
+	"maxFeePerBlobGas":     true,
+	"effectiveGasPrice":    true,
+}
+
+// MarshalJSONDecoded implements PolyBlock. See marshalDecoded.
+func (i *implPolyBlock) MarshalJSONDecoded() ([]byte, error) {
+	fields := decodedValue(reflect.ValueOf(i.inner)).(map[string]any)
+	if len(i.inner.Transactions) == 0 && len(i.inner.TransactionHashes) > 0 {
+		fields["transactions"] = i.inner.TransactionHashes
+	}
+	return json.Marshal(fields)
+}
+
+// MarshalJSONDecoded implements PolyTransaction. See marshalDecoded.
+func (i *implPolyTransaction) MarshalJSONDecoded() ([]byte, error) {
+	return marshalDecoded(i.inner)
+}
+
+// MarshalJSONDecoded implements PolyReceipt. See marshalDecoded.
+func (i *implPolyReceipt) MarshalJSONDecoded() ([]byte, error) {
+	return marshalDecoded(i.inner)
+}
+
+// marshalDecoded encodes the raw response with the same keys as its JSON
+// encoding, but with its quantities decoded for reading: timestamps are in
+// RFC3339 UTC, the per gas fees are decimal gwei, and the other quantities are
+// decimal numbers, except the signature values r and s. Hashes, addresses, and
+// data stay hex, and null quantities stay null. The output doesn't decode back
+// into the raw types, so MarshalJSON is what should be used for storage.
+func marshalDecoded(v any) ([]byte, error) {
+	return json.Marshal(decodedValue(reflect.ValueOf(v)))
+}
+
+// decodedValue converts the raw value for marshalDecoded. Structs become maps
+// keyed by their fields' JSON names.
+func decodedValue(v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return decodedValue(v.Elem())
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		values := make([]any, v.Len())
+		for idx := range values {
+			values[idx] = decodedValue(v.Index(idx))
+		}
+		return values
+	case reflect.Struct:
+		fields := make(map[string]any)
+		for idx := 0; idx < v.NumField(); idx++ {
+			field := v.Type().Field(idx)
+			name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			value := v.Field(idx)
+			if strings.Contains(opts, "omitempty") && (value.IsZero() || value.Kind() == reflect.Slice && value.Len() == 0) {
+				continue
+			}
+			if q, ok := value.Interface().(RawQuantityResponse); ok {
+				fields[name] = decodedQuantity(name, q)
+				continue
+			}
+			fields[name] = decodedValue(value)
+		}
+		return fields
+	}
+	return v.Interface()
+}
+
+// decodedQuantity decodes the quantity with the given key for marshalDecoded.
+func decodedQuantity(name string, q RawQuantityResponse) any {
+	switch {
+	case q.IsEmpty():
+		return nil
+	case name == "timestamp":
+		return time.Unix(int64(q.ToUint64()), 0).UTC().Format(time.RFC3339)
+	case gweiQuantities[name]:
+		return json.Number(formatGwei(q.ToBigInt()))
+	case name == "r" || name == "s":
+		return q
+	}
+	return json.Number(q.ToBigInt().String())
+}
@@ -0,0 +1,82 @@
+package rpctypes
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMarshalJSONDecoded(t *testing.T) {
+	hash := "0x00000000000000000000000000000000000000000000000000000000000000aa"
+	raw := &RawBlockResponse{
+		Number:        "0x10",
+		Hash:          RawData32Response(hash),
+		Nonce:         "0x0000000000000000",
+		Timestamp:     "0x65a4b2c0",
+		BaseFeePerGas: "0x3b9aca01",
+		GasLimit:      "0x1c9c380",
+		Transactions: []RawTransactionResponse{{
+			Hash:     RawData32Response(hash),
+			Nonce:    "0x2a",
+			GasPrice: "0x77359400",
+			Value:    "0xde0b6b3a7640000",
+			R:        "0x1f",
+		}},
+	}
+	block := NewPolyBlock(raw)
+
+	data, err := block.MarshalJSONDecoded()
+	if err != nil {
+		t.Fatalf("could not encode block: %v", err)
+	}
+	var decoded struct {
+		Number          json.Number `json:"number"`
+		Hash            string      `json:"hash"`
+		Nonce           string      `json:"nonce"`
+		Timestamp       string      `json:"timestamp"`
+		BaseFeePerGas   json.Number `json:"baseFeePerGas"`
+		GasLimit        json.Number `json:"gasLimit"`
+		TotalDifficulty *string     `json:"totalDifficulty"`
+		Transactions    []struct {
+			Hash     string      `json:"hash"`
+			Nonce    json.Number `json:"nonce"`
+			GasPrice json.Number `json:"gasPrice"`
+			Value    json.Number `json:"value"`
+			R        string      `json:"r"`
+		} `json:"transactions"`
+	}
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("could not decode %s: %v", data, err)
+	}
+
+	if decoded.Number != "16" || decoded.Hash != hash || decoded.Nonce != "0x0000000000000000" ||
+		decoded.Timestamp != "2024-01-15T04:21:20Z" || decoded.BaseFeePerGas != "1.000000001" ||
+		decoded.GasLimit != "30000000" || decoded.TotalDifficulty != nil {
+		t.Errorf("unexpected decoded block %s", data)
+	}
+	if len(decoded.Transactions) != 1 {
+		t.Fatalf("expected 1 decoded transaction, got %s", data)
+	}
+	tx := decoded.Transactions[0]
+	if tx.Hash != hash || tx.Nonce != "42" || tx.GasPrice != "2" || tx.Value != "1000000000000000000" || tx.R != "0x1f" {
+		t.Errorf("unexpected decoded transaction %s", data)
+	}
+
+	// The default encoding is still the raw hex.
+	encoded, err := block.MarshalJSON()
+	if err != nil {
+		t.Fatalf("could not encode block: %v", err)
+	}
+	if !strings.Contains(string(encoded), `"number":"0x10"`) || !strings.Contains(string(encoded), `"timestamp":"0x65a4b2c0"`) {
+		t.Errorf("expected the raw encoding to stay hex, got %s", encoded)
+	}
+
+	// Hashes-only blocks write the hashes as the transactions.
+	data, err = NewPolyBlock(&RawBlockResponse{TransactionHashes: []RawData32Response{RawData32Response(hash)}}).MarshalJSONDecoded()
+	if err != nil {
+		t.Fatalf("could not encode block: %v", err)
+	}
+	if !strings.Contains(string(data), `"transactions":["`+hash+`"]`) {
+		t.Errorf("expected the transaction hashes, got %s", data)
+	}
+}
@@ -0,0 +1,146 @@
+package rpctypes
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// DecodeWithDiagnostics checks that every field of the block and its
+// transactions decodes, collecting the errors by field name, e.g. gasUsed or
+// transactions[3].value, instead of stopping at the first one. The accessors
+// of the returned block are best effort: malformed fields decode to zero as
+// usual. Null or missing fields aren't errors. The map is empty if the block
+// is well formed.
+func (r *RawBlockResponse) DecodeWithDiagnostics() (PolyBlock, map[string]error) {
+	d := make(diagnostics)
+
+	d.quantity("number", r.Number)
+	d.data("hash", string(r.Hash), 32)
+	d.data("parentHash", string(r.ParentHash), 32)
+	d.data("nonce", string(r.Nonce), 8)
+	d.data("sha3Uncles", string(r.SHA3Uncles), 32)
+	d.data("logsBloom", string(r.LogsBloom), 256)
+	d.data("transactionsRoot", string(r.TransactionsRoot), 32)
+	d.data("stateRoot", string(r.StateRoot), 32)
+	d.data("receiptsRoot", string(r.ReceiptsRoot), 32)
+	d.data("miner", string(r.Miner), 20)
+	d.quantity("difficulty", r.Difficulty)
+	d.quantity("totalDifficulty", r.TotalDifficulty)
+	d.data("extraData", string(r.ExtraData), -1)
+	d.quantity("size", r.Size)
+	d.quantity("gasLimit", r.GasLimit)
+	d.quantity("gasUsed", r.GasUsed)
+	d.quantity("timestamp", r.Timestamp)
+	d.quantity("baseFeePerGas", r.BaseFeePerGas)
+	d.data("mixHash", string(r.MixHash), 32)
+	d.data("withdrawalsRoot", string(r.WithdrawalsRoot), 32)
+	d.quantity("blobGasUsed", r.BlobGasUsed)
+	d.quantity("excessBlobGas", r.ExcessBlobGas)
+	d.data("parentBeaconBlockRoot", string(r.ParentBeaconBlockRoot), 32)
+	d.data("requestsHash", string(r.RequestsHash), 32)
+	for idx, h := range r.Uncles {
+		d.data(fmt.Sprintf("uncles[%d]", idx), string(h), 32)
+	}
+	for idx, h := range r.TransactionHashes {
+		d.data(fmt.Sprintf("transactions[%d]", idx), string(h), 32)
+	}
+	for idx, w := range r.Withdrawals {
+		prefix := fmt.Sprintf("withdrawals[%d].", idx)
+		d.quantity(prefix+"index", w.Index)
+		d.quantity(prefix+"validatorIndex", w.ValidatorIndex)
+		d.data(prefix+"address", string(w.Address), 20)
+		d.quantity(prefix+"amount", w.Amount)
+	}
+	for idx, req := range r.Requests {
+		field := fmt.Sprintf("requests[%d]", idx)
+		d.data(field, string(req), -1)
+		// A request starts with its type byte, see Requests.
+		if req == "0x" {
+			d[field] = errors.New("expected the request type, got no bytes")
+		}
+	}
+
+	for idx := range r.Transactions {
+		tx := &r.Transactions[idx]
+		prefix := fmt.Sprintf("transactions[%d].", idx)
+		d.data(prefix+"blockHash", string(tx.BlockHash), 32)
+		d.quantity(prefix+"blockNumber", tx.BlockNumber)
+		d.data(prefix+"from", string(tx.From), 20)
+		d.quantity(prefix+"gas", tx.Gas)
+		d.quantity(prefix+"gasPrice", tx.GasPrice)
+		d.quantity(prefix+"maxPriorityFeePerGas", tx.MaxPriorityFeePerGas)
+		d.quantity(prefix+"maxFeePerGas", tx.MaxFeePerGas)
+		d.data(prefix+"hash", string(tx.Hash), 32)
+		d.data(prefix+"input", string(tx.Input), -1)
+		d.quantity(prefix+"nonce", tx.Nonce)
+		d.data(prefix+"to", string(tx.To), 20)
+		d.quantity(prefix+"transactionIndex", tx.TransactionIndex)
+		d.quantity(prefix+"value", tx.Value)
+		d.quantity(prefix+"v", tx.V)
+		d.quantity(prefix+"r", tx.R)
+		d.quantity(prefix+"s", tx.S)
+		d.quantity(prefix+"type", tx.Type)
+		d.quantity(prefix+"chainId", tx.ChainID)
+		d.quantity(prefix+"maxFeePerBlobGas", tx.MaxFeePerBlobGas)
+		for i, h := range tx.BlobVersionedHashes {
+			d.data(fmt.Sprintf("%sblobVersionedHashes[%d]", prefix, i), string(h), 32)
+		}
+		for i, tuple := range tx.AccessList {
+			d.data(fmt.Sprintf("%saccessList[%d].address", prefix, i), string(tuple.Address), 20)
+			for j, key := range tuple.StorageKeys {
+				d.data(fmt.Sprintf("%saccessList[%d].storageKeys[%d]", prefix, i, j), string(key), 32)
+			}
+		}
+	}
+
+	return NewPolyBlock(r), d
+}
+
+// diagnostics collects decoding errors by field name.
+type diagnostics map[string]error
+
+func (d diagnostics) quantity(field string, raw RawQuantityResponse) {
+	if raw == "" {
+		return
+	}
+	// Leading zeros aren't allowed by the spec, but some nodes return them and
+	// they decode fine, so only the prefix, digits and size are checked.
+	s := string(raw)
+	if len(s) < 2 || s[:2] != "0x" {
+		d[field] = hexutil.ErrMissingPrefix
+		return
+	}
+	n, ok := new(big.Int).SetString(s[2:], 16)
+	if !ok {
+		d[field] = hexutil.ErrSyntax
+		return
+	}
+	if n.BitLen() > 256 {
+		d[field] = hexutil.ErrBig256Range
+	}
+}
+
+func (d diagnostics) data(field, raw string, size int) {
+	if raw == "" {
+		return
+	}
+	if len(raw) < 2 || raw[:2] != "0x" {
+		d[field] = hexutil.ErrMissingPrefix
+		return
+	}
+	if size < 0 && MaxDataBytes > 0 && (len(raw)-2)/2 > MaxDataBytes {
+		d[field] = ErrDataTooLarge
+		return
+	}
+	data, err := hexutil.Decode(raw)
+	if err != nil {
+		d[field] = err
+		return
+	}
+	if size >= 0 && len(data) != size {
+		d[field] = fmt.Errorf("expected %d bytes, got %d", size, len(data))
+	}
+}
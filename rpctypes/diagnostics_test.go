@@ -0,0 +1,55 @@
+package rpctypes
+
+import (
+	"encoding/json"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+func TestDecodeWithDiagnostics(t *testing.T) {
+	data := []byte(`{
+		"number": "0x10",
+		"hash": "0x1234",
+		"miner": "0x00000000000000000000000000000000000000aa",
+		"gasUsed": "12345",
+		"extraData": "0xzz",
+		"logsBloom": null,
+		"transactions": [
+			{"hash": "0x0000000000000000000000000000000000000000000000000000000000000001", "value": "0xde0b6b3a7640000", "v": "0x00"},
+			{"hash": "0x0000000000000000000000000000000000000000000000000000000000000002", "value": "0xnope", "input": "0x123",
+				"accessList": [{"address": "0x00000000000000000000000000000000000000bb", "storageKeys": [
+					"0x0000000000000000000000000000000000000000000000000000000000000001", "0x01"]}]}
+		],
+		"withdrawals": [
+			{"index": "0x1", "validatorIndex": "0x2", "address": "0xbb", "amount": "0x3"}
+		],
+		"requests": ["0x00aa", "0x"]
+	}`)
+
+	raw := new(RawBlockResponse)
+	if err := json.Unmarshal(data, raw); err != nil {
+		t.Fatalf("could not decode block: %v", err)
+	}
+	block, diags := raw.DecodeWithDiagnostics()
+
+	expected := []string{"hash", "gasUsed", "extraData", "transactions[1].value", "transactions[1].input",
+		"transactions[1].accessList[0].storageKeys[1]", "withdrawals[0].address", "requests[1]"}
+	for _, field := range expected {
+		if diags[field] == nil {
+			t.Errorf("expected a diagnostic for %s", field)
+		}
+	}
+	if len(diags) != len(expected) {
+		t.Errorf("expected %d diagnostics, got %d: %v", len(expected), len(diags), diags)
+	}
+
+	if block.Number().Int64() != 16 || block.Miner() != ethcommon.HexToAddress("0xaa") {
+		t.Errorf("expected the well formed fields to decode, got number %s and miner %s", block.Number(), block.Miner())
+	}
+
+	clean := &RawBlockResponse{Number: "0x1", GasUsed: "0x5208", Transactions: []RawTransactionResponse{{Input: "0x", Value: "0x0"}}}
+	if _, diags = clean.DecodeWithDiagnostics(); len(diags) != 0 {
+		t.Errorf("expected no diagnostics for a well formed block, got %v", diags)
+	}
+}
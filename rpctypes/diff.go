@@ -0,0 +1,134 @@
+package rpctypes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// FieldDiff is a field that differs between two values. The field is the JSON
+// path to it, e.g. transactions[2].nonce, and the values are its JSON
+// encodings, or null if one side doesn't have the field.
+type FieldDiff struct {
+	Field string
+	A     string
+	B     string
+}
+
+func (d FieldDiff) String() string {
+	return fmt.Sprintf("%s: %s != %s", d.Field, d.A, d.B)
+}
+
+// BlockEqual reports whether the blocks have the same fields, including their
+// transactions, regardless of key order or hex case. Blocks that can't be
+// compared because one fails to encode are never equal.
+func BlockEqual(a, b PolyBlock) bool {
+	diffs, err := BlockDiff(a, b)
+	return err == nil && len(diffs) == 0
+}
+
+// BlockDiff returns the fields that differ between the blocks, sorted by
+// field. It compares the blocks' canonical JSON, like ContentHash, so the
+// result doesn't depend on key order or hex case. Transactions are compared
+// by their position in the block. An error is returned if either block can't
+// be encoded.
+func BlockDiff(a, b PolyBlock) ([]FieldDiff, error) {
+	return diffJSON(a, b)
+}
+
+// TransactionEqual reports whether the transactions have the same fields,
+// regardless of key order or hex case. Transactions that can't be compared
+// because one fails to encode are never equal.
+func TransactionEqual(a, b PolyTransaction) bool {
+	diffs, err := TransactionDiff(a, b)
+	return err == nil && len(diffs) == 0
+}
+
+// TransactionDiff returns the fields that differ between the transactions,
+// sorted by field. An error is returned if either transaction can't be
+// encoded.
+func TransactionDiff(a, b PolyTransaction) ([]FieldDiff, error) {
+	return diffJSON(a, b)
+}
+
+func diffJSON(a, b any) ([]FieldDiff, error) {
+	va, err := decodeCanonical(a)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode value to diff: %w", err)
+	}
+	vb, err := decodeCanonical(b)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode value to diff: %w", err)
+	}
+
+	var diffs []FieldDiff
+	diffValues("", va, vb, &diffs)
+	sort.SliceStable(diffs, func(i, j int) bool { return diffs[i].Field < diffs[j].Field })
+	return diffs, nil
+}
+
+func decodeCanonical(v any) (any, error) {
+	data, err := CanonicalJSON(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	err = decoder.Decode(&value)
+	return value, err
+}
+
+// diffValues walks the decoded JSON values, recursing into the objects and
+// arrays both sides have, and appends the leaves that differ.
+func diffValues(path string, a, b any, diffs *[]FieldDiff) {
+	switch va := a.(type) {
+	case map[string]any:
+		if vb, ok := b.(map[string]any); ok {
+			for key, elem := range va {
+				diffValues(joinPath(path, key), elem, vb[key], diffs)
+			}
+			for key, elem := range vb {
+				if _, ok := va[key]; !ok {
+					diffValues(joinPath(path, key), nil, elem, diffs)
+				}
+			}
+			return
+		}
+	case []any:
+		if vb, ok := b.([]any); ok {
+			for idx := 0; idx < len(va) || idx < len(vb); idx++ {
+				var ea, eb any
+				if idx < len(va) {
+					ea = va[idx]
+				}
+				if idx < len(vb) {
+					eb = vb[idx]
+				}
+				diffValues(fmt.Sprintf("%s[%d]", path, idx), ea, eb, diffs)
+			}
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*diffs = append(*diffs, FieldDiff{Field: path, A: encodeValue(a), B: encodeValue(b)})
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func encodeValue(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
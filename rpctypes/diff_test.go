@@ -0,0 +1,79 @@
+package rpctypes
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// failingBlock is a block that can't be encoded.
+type failingBlock struct {
+	PolyBlock
+}
+
+func (failingBlock) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("encoding failed")
+}
+
+func TestBlockDiff(t *testing.T) {
+	decode := func(data string) PolyBlock {
+		raw := new(RawBlockResponse)
+		if err := json.Unmarshal([]byte(data), raw); err != nil {
+			t.Fatalf("could not decode block: %v", err)
+		}
+		return NewPolyBlock(raw)
+	}
+
+	// The same block from two nodes, with a different key order and hex case.
+	a := decode(`{"number": "0x10", "hash": "0x00000000000000000000000000000000000000000000000000000000000000ab", "transactions": [
+		{"hash": "0x01", "nonce": "0x1", "from": "0x00000000000000000000000000000000000000aa"},
+		{"hash": "0x02", "nonce": "0x2", "from": "0x00000000000000000000000000000000000000aa"}]}`)
+	b := decode(`{"transactions": [
+		{"from": "0x00000000000000000000000000000000000000AA", "nonce": "0x1", "hash": "0x01"},
+		{"from": "0x00000000000000000000000000000000000000AA", "nonce": "0x2", "hash": "0x02"}],
+		"hash": "0x00000000000000000000000000000000000000000000000000000000000000AB", "number": "0x10"}`)
+	if !BlockEqual(a, b) {
+		diffs, err := BlockDiff(a, b)
+		t.Errorf("expected the blocks to be equal, got %v (%v)", diffs, err)
+	}
+
+	// The second transaction's nonce differs.
+	c := decode(`{"number": "0x10", "hash": "0x00000000000000000000000000000000000000000000000000000000000000ab", "transactions": [
+		{"hash": "0x01", "nonce": "0x1", "from": "0x00000000000000000000000000000000000000aa"},
+		{"hash": "0x02", "nonce": "0x3", "from": "0x00000000000000000000000000000000000000aa"}]}`)
+	if BlockEqual(a, c) {
+		t.Fatalf("expected the blocks to differ")
+	}
+	diffs, err := BlockDiff(a, c)
+	if err != nil {
+		t.Fatalf("could not diff blocks: %v", err)
+	}
+	if len(diffs) != 1 || diffs[0] != (FieldDiff{Field: "transactions[1].nonce", A: `"0x2"`, B: `"0x3"`}) {
+		t.Errorf("expected only the nonce to differ, got %v", diffs)
+	}
+
+	txDiffs, err := TransactionDiff(a.Transactions()[1], c.Transactions()[1])
+	if err != nil || len(txDiffs) != 1 || txDiffs[0].Field != "nonce" {
+		t.Errorf("expected only the transaction's nonce to differ, got %v", txDiffs)
+	}
+	if !TransactionEqual(a.Transactions()[0], c.Transactions()[0]) {
+		t.Errorf("expected the first transactions to be equal")
+	}
+
+	// A missing transaction shows up as null on that side.
+	d := decode(`{"number": "0x10", "hash": "0x00000000000000000000000000000000000000000000000000000000000000ab", "transactions": [
+		{"hash": "0x01", "nonce": "0x1", "from": "0x00000000000000000000000000000000000000aa"}]}`)
+	diffs, err = BlockDiff(a, d)
+	if err != nil || len(diffs) != 1 || diffs[0].Field != "transactions[1]" || diffs[0].B != "null" {
+		t.Errorf("expected the missing transaction to differ, got %v (%v)", diffs, err)
+	}
+
+	// A block that can't be encoded is an error and never equal.
+	failing := failingBlock{a}
+	if _, err = BlockDiff(a, failing); err == nil {
+		t.Errorf("expected an error diffing a block that can't be encoded")
+	}
+	if BlockEqual(failing, failing) {
+		t.Errorf("expected a block that can't be encoded not to be equal")
+	}
+}
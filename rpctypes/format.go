@@ -0,0 +1,37 @@
+package rpctypes
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// FormatBlock renders the block's main fields as an aligned key/value table
+// for terminal output. The timestamp is in RFC3339 UTC and the base fee is in
+// gwei.
+func FormatBlock(b PolyBlock) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "Number:       %v\n", b.Number())
+	fmt.Fprintf(&sb, "Hash:         %s\n", b.Hash().Hex())
+	fmt.Fprintf(&sb, "Timestamp:    %s\n", time.Unix(int64(b.Time()), 0).UTC().Format(time.RFC3339))
+	fmt.Fprintf(&sb, "Miner:        %s\n", b.Miner().Hex())
+
+	fmt.Fprintf(&sb, "Gas:          %d / %d (%.2f%%)\n", b.GasUsed(), b.GasLimit(), b.GasUtilization()*100)
+	fmt.Fprintf(&sb, "Transactions: %d\n", b.TransactionCount())
+	fmt.Fprintf(&sb, "Base Fee:     %s gwei\n", formatGwei(b.BaseFee()))
+
+	return sb.String()
+}
+
+// formatGwei converts the wei amount to gwei without trailing zeros.
+func formatGwei(wei *big.Int) string {
+	s := weiToUnit(wei, params.GWei).Text('f', 9)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
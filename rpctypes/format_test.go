@@ -0,0 +1,34 @@
+package rpctypes
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatBlock(t *testing.T) {
+	block := NewPolyBlock(&RawBlockResponse{
+		Number:        "0x64",
+		Hash:          "0x00000000000000000000000000000000000000000000000000000000000000ff",
+		Timestamp:     "0x64a7b3c0",
+		Miner:         "0x00000000000000000000000000000000000000aa",
+		GasUsed:       "0xe4e1c0",
+		GasLimit:      "0x1c9c380",
+		BaseFeePerGas: "0x6fc23ac01",
+		Transactions:  []RawTransactionResponse{{Hash: "0x01"}, {Hash: "0x02"}},
+	})
+
+	formatted := FormatBlock(block)
+	for _, expected := range []string{
+		"Number:       100\n",
+		"Hash:         0x00000000000000000000000000000000000000000000000000000000000000ff\n",
+		"Timestamp:    2023-07-07T06:42:08Z\n",
+		"Miner:        0x00000000000000000000000000000000000000AA\n",
+		"Gas:          15000000 / 30000000 (50.00%)\n",
+		"Transactions: 2\n",
+		"Base Fee:     30.000000001 gwei\n",
+	} {
+		if !strings.Contains(formatted, expected) {
+			t.Errorf("expected %q in:\n%s", expected, formatted)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package rpctypes
+
+import (
+	"fmt"
+	"math/big"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// EncodeHeaderRLP RLP encodes the block header in consensus order. The fork
+// dependent fields are included when the block has them: baseFeePerGas from
+// London, withdrawalsRoot from Shanghai, and the blob gas fields and parent
+// beacon block root from Cancun, and requestsHash from Prague. Unlike the
+// other accessors, malformed or missing required fields return an error, since
+// the encoding would be wrong.
+func (i *implPolyBlock) EncodeHeaderRLP() ([]byte, error) {
+	header, err := i.header()
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := rlp.EncodeToBytes(header)
+	if err != nil || i.inner.RequestsHash == "" {
+		return encoded, err
+	}
+
+	// The header type doesn't have the Prague requests hash yet, so append it
+	// to the encoded field list.
+	if header.ParentBeaconRoot == nil {
+		return nil, fmt.Errorf("block has a requests hash but no parent beacon block root")
+	}
+	d := headerDecoder{}
+	requestsHash := d.hash("requestsHash", i.inner.RequestsHash)
+	if d.err != nil {
+		return nil, d.err
+	}
+	var fields []rlp.RawValue
+	if err = rlp.DecodeBytes(encoded, &fields); err != nil {
+		return nil, err
+	}
+	field, err := rlp.EncodeToBytes(requestsHash)
+	if err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(append(fields, field))
+}
+
+// ComputeHash computes the block hash from the header fields. It should match
+// Hash, otherwise the block was misreported or tampered with.
+func (i *implPolyBlock) ComputeHash() (ethcommon.Hash, error) {
+	encoded, err := i.EncodeHeaderRLP()
+	if err != nil {
+		return ethcommon.Hash{}, err
+	}
+	return crypto.Keccak256Hash(encoded), nil
+}
+
+func (i *implPolyBlock) header() (*ethtypes.Header, error) {
+	b := i.inner
+	d := headerDecoder{}
+	header := &ethtypes.Header{
+		ParentHash:  d.hash("parentHash", b.ParentHash),
+		UncleHash:   d.hash("sha3Uncles", b.SHA3Uncles),
+		Coinbase:    d.address("miner", b.Miner),
+		Root:        d.hash("stateRoot", b.StateRoot),
+		TxHash:      d.hash("transactionsRoot", b.TransactionsRoot),
+		ReceiptHash: d.hash("receiptsRoot", b.ReceiptsRoot),
+		Bloom:       ethtypes.BytesToBloom(d.bytes("logsBloom", string(b.LogsBloom), ethtypes.BloomByteLength)),
+		Difficulty:  d.big("difficulty", b.Difficulty),
+		Number:      d.big("number", b.Number),
+		GasLimit:    d.uint64("gasLimit", b.GasLimit),
+		GasUsed:     d.uint64("gasUsed", b.GasUsed),
+		Time:        d.uint64("timestamp", b.Timestamp),
+		Extra:       d.bytes("extraData", string(b.ExtraData), -1),
+		MixDigest:   d.hash("mixHash", b.MixHash),
+	}
+	copy(header.Nonce[:], d.bytes("nonce", string(b.Nonce), len(header.Nonce)))
+
+	// The optional fields have to be contiguous, since a later field can't be
+	// encoded without the earlier ones.
+	if b.BaseFeePerGas != "" {
+		header.BaseFee = d.big("baseFeePerGas", b.BaseFeePerGas)
+	}
+	if b.WithdrawalsRoot != "" {
+		h := d.hash("withdrawalsRoot", b.WithdrawalsRoot)
+		header.WithdrawalsHash = &h
+	}
+	if b.BlobGasUsed != "" || b.ExcessBlobGas != "" || b.ParentBeaconBlockRoot != "" {
+		blobGasUsed := d.uint64("blobGasUsed", b.BlobGasUsed)
+		excessBlobGas := d.uint64("excessBlobGas", b.ExcessBlobGas)
+		root := d.hash("parentBeaconBlockRoot", b.ParentBeaconBlockRoot)
+		header.BlobGasUsed = &blobGasUsed
+		header.ExcessBlobGas = &excessBlobGas
+		header.ParentBeaconRoot = &root
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	if header.WithdrawalsHash != nil && header.BaseFee == nil {
+		return nil, fmt.Errorf("block has a withdrawals root but no base fee")
+	}
+	if header.BlobGasUsed != nil && header.WithdrawalsHash == nil {
+		return nil, fmt.Errorf("block has blob gas fields but no withdrawals root")
+	}
+
+	return header, nil
+}
+
+// headerDecoder strictly decodes header fields, keeping the first error so the
+// fields can be decoded in a single expression.
+type headerDecoder struct {
+	err error
+}
+
+func (d *headerDecoder) fail(field string, err error) {
+	if d.err == nil {
+		d.err = fmt.Errorf("unable to decode header field %s: %w", field, err)
+	}
+}
+
+func (d *headerDecoder) bytes(field, raw string, size int) []byte {
+	data, err := hexutil.Decode(raw)
+	if err != nil {
+		d.fail(field, err)
+		return nil
+	}
+	if size >= 0 && len(data) != size {
+		d.fail(field, fmt.Errorf("expected %d bytes, got %d", size, len(data)))
+		return nil
+	}
+	return data
+}
+
+func (d *headerDecoder) hash(field string, raw RawData32Response) ethcommon.Hash {
+	return ethcommon.BytesToHash(d.bytes(field, string(raw), ethcommon.HashLength))
+}
+
+func (d *headerDecoder) address(field string, raw RawData20Response) ethcommon.Address {
+	return ethcommon.BytesToAddress(d.bytes(field, string(raw), ethcommon.AddressLength))
+}
+
+func (d *headerDecoder) big(field string, raw RawQuantityResponse) *big.Int {
+	n, err := hexutil.DecodeBig(string(raw))
+	if err != nil {
+		d.fail(field, err)
+		return new(big.Int)
+	}
+	return n
+}
+
+func (d *headerDecoder) uint64(field string, raw RawQuantityResponse) uint64 {
+	n, err := hexutil.DecodeUint64(string(raw))
+	if err != nil {
+		d.fail(field, err)
+		return 0
+	}
+	return n
+}
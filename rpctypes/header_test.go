@@ -0,0 +1,187 @@
+package rpctypes
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestComputeHash(t *testing.T) {
+	withdrawalsRoot := ethcommon.HexToHash("0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+	beaconRoot := ethcommon.HexToHash("0x0102")
+	blobGasUsed, excessBlobGas := uint64(131072), uint64(393216)
+
+	base := func() *ethtypes.Header {
+		return &ethtypes.Header{
+			ParentHash:  ethcommon.HexToHash("0x01"),
+			UncleHash:   ethtypes.EmptyUncleHash,
+			Coinbase:    ethcommon.HexToAddress("0x95222290dd7278aa3ddd389cc1e1d165cc4bafe5"),
+			Root:        ethcommon.HexToHash("0x02"),
+			TxHash:      ethcommon.HexToHash("0x03"),
+			ReceiptHash: ethcommon.HexToHash("0x04"),
+			Difficulty:  big.NewInt(0),
+			Number:      big.NewInt(18000000),
+			GasLimit:    30000000,
+			GasUsed:     12345678,
+			Time:        1693000000,
+			Extra:       []byte("beaverbuild.org"),
+			MixDigest:   ethcommon.HexToHash("0x05"),
+		}
+	}
+
+	legacy := base()
+	legacy.Difficulty = big.NewInt(131072)
+	legacy.Nonce = ethtypes.EncodeNonce(42)
+
+	london := base()
+	london.BaseFee = big.NewInt(15000000000)
+
+	shanghai := base()
+	shanghai.BaseFee = big.NewInt(15000000000)
+	shanghai.WithdrawalsHash = &withdrawalsRoot
+
+	cancun := base()
+	cancun.BaseFee = big.NewInt(15000000000)
+	cancun.WithdrawalsHash = &withdrawalsRoot
+	cancun.BlobGasUsed = &blobGasUsed
+	cancun.ExcessBlobGas = &excessBlobGas
+	cancun.ParentBeaconRoot = &beaconRoot
+
+	type test struct {
+		name   string
+		header *ethtypes.Header
+	}
+
+	tests := []test{
+		{name: "legacy", header: legacy},
+		{name: "london", header: london},
+		{name: "shanghai", header: shanghai},
+		{name: "cancun", header: cancun},
+	}
+
+	for _, test := range tests {
+		data, err := json.Marshal(test.header)
+		if err != nil {
+			t.Fatalf("%s: could not encode header: %v", test.name, err)
+		}
+		raw := new(RawBlockResponse)
+		if err = json.Unmarshal(data, raw); err != nil {
+			t.Fatalf("%s: could not decode header: %v", test.name, err)
+		}
+		block := NewPolyBlock(raw)
+
+		encoded, err := block.EncodeHeaderRLP()
+		if err != nil {
+			t.Fatalf("%s: could not encode header RLP: %v", test.name, err)
+		}
+		expected, err := rlp.EncodeToBytes(test.header)
+		if err != nil {
+			t.Fatalf("%s: could not encode expected header RLP: %v", test.name, err)
+		}
+		if string(encoded) != string(expected) {
+			t.Errorf("%s: header RLP doesn't match", test.name)
+		}
+
+		hash, err := block.ComputeHash()
+		if err != nil {
+			t.Fatalf("%s: could not compute hash: %v", test.name, err)
+		}
+		if hash != block.Hash() || hash != test.header.Hash() {
+			t.Errorf("%s: expected hash %s, got %s", test.name, block.Hash(), hash)
+		}
+
+		raw.ExtraData = "0x00"
+		if hash, _ = block.ComputeHash(); hash == block.Hash() {
+			t.Errorf("%s: expected a tampered header to have a different hash", test.name)
+		}
+	}
+
+	if _, err := NewPolyBlock(&RawBlockResponse{}).ComputeHash(); err == nil {
+		t.Errorf("expected an error computing the hash of an empty block")
+	}
+}
+
+func TestPragueRequests(t *testing.T) {
+	withdrawalsRoot := ethcommon.HexToHash("0x01")
+	beaconRoot := ethcommon.HexToHash("0x02")
+	blobGasUsed, excessBlobGas := uint64(0), uint64(0)
+	header := &ethtypes.Header{
+		Difficulty:       big.NewInt(0),
+		Number:           big.NewInt(22431084),
+		BaseFee:          big.NewInt(7),
+		WithdrawalsHash:  &withdrawalsRoot,
+		BlobGasUsed:      &blobGasUsed,
+		ExcessBlobGas:    &excessBlobGas,
+		ParentBeaconRoot: &beaconRoot,
+	}
+	data, err := json.Marshal(header)
+	if err != nil {
+		t.Fatalf("could not encode header: %v", err)
+	}
+
+	cancun := new(RawBlockResponse)
+	if err = json.Unmarshal(data, cancun); err != nil {
+		t.Fatalf("could not decode header: %v", err)
+	}
+	if requests, err := NewPolyBlock(cancun).Requests(); err != nil || len(requests) != 0 {
+		t.Errorf("expected no requests before Prague, got %v %v", requests, err)
+	}
+	if NewPolyBlock(cancun).RequestsHash() != (ethcommon.Hash{}) {
+		t.Errorf("expected no requests hash before Prague")
+	}
+
+	prague := new(RawBlockResponse)
+	if err = json.Unmarshal(data, prague); err != nil {
+		t.Fatalf("could not decode header: %v", err)
+	}
+	prague.RequestsHash = "0xe3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	prague.Requests = []RawDataResponse{"0x00aabb", "0x01cc"}
+	block := NewPolyBlock(prague)
+
+	requests, err := block.Requests()
+	if err != nil {
+		t.Fatalf("could not decode requests: %v", err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(requests))
+	}
+	if requests[0].Type != DepositRequestType || hexutil.Encode(requests[0].Data) != "0xaabb" {
+		t.Errorf("unexpected deposit request %+v", requests[0])
+	}
+	if requests[1].Type != WithdrawalRequestType || hexutil.Encode(requests[1].Data) != "0xcc" {
+		t.Errorf("unexpected withdrawal request %+v", requests[1])
+	}
+	for _, malformed := range []RawDataResponse{"0x", "0xzz"} {
+		if _, err = NewPolyBlock(&RawBlockResponse{Requests: []RawDataResponse{"0x00aa", malformed}}).Requests(); err == nil {
+			t.Errorf("expected an error for the malformed request %q", malformed)
+		}
+	}
+
+	cancunRLP, err := NewPolyBlock(cancun).EncodeHeaderRLP()
+	if err != nil {
+		t.Fatalf("could not encode Cancun header: %v", err)
+	}
+	pragueRLP, err := block.EncodeHeaderRLP()
+	if err != nil {
+		t.Fatalf("could not encode Prague header: %v", err)
+	}
+	var cancunFields, pragueFields []rlp.RawValue
+	if err = rlp.DecodeBytes(cancunRLP, &cancunFields); err != nil {
+		t.Fatalf("could not decode Cancun header RLP: %v", err)
+	}
+	if err = rlp.DecodeBytes(pragueRLP, &pragueFields); err != nil {
+		t.Fatalf("could not decode Prague header RLP: %v", err)
+	}
+	if len(pragueFields) != len(cancunFields)+1 {
+		t.Fatalf("expected the Prague header to have one more field, got %d and %d", len(pragueFields), len(cancunFields))
+	}
+	var requestsHash ethcommon.Hash
+	if err = rlp.DecodeBytes(pragueFields[len(pragueFields)-1], &requestsHash); err != nil || requestsHash != block.RequestsHash() {
+		t.Errorf("expected the last header field to be the requests hash, got %s", requestsHash)
+	}
+}
@@ -0,0 +1,57 @@
+package rpctypes
+
+import (
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// ToLog decodes the log.
+func (l *RawTxLogs) ToLog() *ethtypes.Log {
+	topics := make([]ethcommon.Hash, 0, len(l.Topics))
+	for _, t := range l.Topics {
+		topics = append(topics, t.ToHash())
+	}
+	return &ethtypes.Log{
+		Address:     l.Address.ToAddress(),
+		Topics:      topics,
+		Data:        l.Data.ToBytes(),
+		BlockNumber: l.BlockNumber.ToUint64(),
+		TxHash:      l.TransactionHash.ToHash(),
+		TxIndex:     uint(l.TransactionIndex.ToUint64()),
+		BlockHash:   l.BlockHash.ToHash(),
+		Index:       uint(l.LogIndex.ToUint64()),
+		Removed:     l.Removed,
+	}
+}
+
+// Topic0 returns the log's first topic, which is the hash of the event's
+// signature unless the event is anonymous. Logs without topics return false.
+func (l *RawTxLogs) Topic0() (ethcommon.Hash, bool) {
+	if len(l.Topics) == 0 {
+		return ethcommon.Hash{}, false
+	}
+	return l.Topics[0].ToHash(), true
+}
+
+// DecodedLogs decodes the receipt's logs.
+func (i *implPolyReceipt) DecodedLogs() []*ethtypes.Log {
+	logs := make([]*ethtypes.Log, 0, len(i.inner.Logs))
+	for idx := range i.inner.Logs {
+		logs = append(logs, i.inner.Logs[idx].ToLog())
+	}
+	return logs
+}
+
+// LogsWithTopic0 decodes the receipt's logs whose first topic is the given
+// one, e.g. the hash of Transfer(address,address,uint256) to find the token
+// transfers.
+func (i *implPolyReceipt) LogsWithTopic0(topic ethcommon.Hash) []*ethtypes.Log {
+	logs := make([]*ethtypes.Log, 0)
+	for idx := range i.inner.Logs {
+		l := &i.inner.Logs[idx]
+		if t, ok := l.Topic0(); ok && t == topic {
+			logs = append(logs, l.ToLog())
+		}
+	}
+	return logs
+}
@@ -0,0 +1,99 @@
+package rpctypes
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+// ReceiptAlignmentError lists the receipts that couldn't be matched to a
+// block's transactions.
+type ReceiptAlignmentError struct {
+	// Missing are the block's transactions without a receipt.
+	Missing []ethcommon.Hash
+	// Unexpected are the receipts for transactions that aren't in the block.
+	Unexpected []ethcommon.Hash
+	// Mismatched are the receipts whose transaction index or block hash
+	// doesn't match the transaction's position in the block.
+	Mismatched []ethcommon.Hash
+}
+
+func (e *ReceiptAlignmentError) Error() string {
+	problems := make([]string, 0, 3)
+	if len(e.Missing) > 0 {
+		problems = append(problems, fmt.Sprintf("%d missing %v", len(e.Missing), e.Missing))
+	}
+	if len(e.Unexpected) > 0 {
+		problems = append(problems, fmt.Sprintf("%d unexpected %v", len(e.Unexpected), e.Unexpected))
+	}
+	if len(e.Mismatched) > 0 {
+		problems = append(problems, fmt.Sprintf("%d mismatched %v", len(e.Mismatched), e.Mismatched))
+	}
+	return "receipts don't match the block: " + strings.Join(problems, ", ")
+}
+
+// AlignReceipts decodes a batch of eth_getTransactionReceipt responses, in any
+// order, and returns the receipts ordered by the index of their transaction in
+// the block. Each receipt is matched to the block's transactions by its
+// transaction hash and checked against the transaction's index and the block
+// hash, rather than trusting the order or ids of the batch. Null responses,
+// for receipts the node didn't find, are skipped.
+//
+// If any receipt is missing, unexpected or mismatched, a
+// *ReceiptAlignmentError listing them is returned along with the receipts
+// that could be aligned, where the missing ones are nil.
+func AlignReceipts(block PolyBlock, responses []*json.RawMessage) (PolyReceipts, error) {
+	hashes := block.TransactionHashes()
+	positions := make(map[ethcommon.Hash]int, len(hashes))
+	for idx, h := range hashes {
+		positions[h] = idx
+	}
+
+	aligned := make(PolyReceipts, len(hashes))
+	alignErr := &ReceiptAlignmentError{}
+	for _, response := range responses {
+		if response == nil || string(*response) == "null" {
+			continue
+		}
+
+		raw := new(RawTxReceipt)
+		if err := json.Unmarshal(*response, raw); err != nil {
+			return nil, fmt.Errorf("unable to decode receipt: %w", err)
+		}
+		receipt := NewPolyReceipt(raw)
+
+		hash := receipt.TransactionHash()
+		idx, ok := positions[hash]
+		if !ok {
+			alignErr.Unexpected = append(alignErr.Unexpected, hash)
+			continue
+		}
+		if receipt.TransactionIndex() != uint64(idx) || (raw.BlockHash != "" && receipt.BlockHash() != block.Hash()) {
+			alignErr.Mismatched = append(alignErr.Mismatched, hash)
+			continue
+		}
+		aligned[idx] = receipt
+	}
+
+	for idx, receipt := range aligned {
+		if receipt == nil && !containsHash(alignErr.Mismatched, hashes[idx]) {
+			alignErr.Missing = append(alignErr.Missing, hashes[idx])
+		}
+	}
+
+	if len(alignErr.Missing) > 0 || len(alignErr.Unexpected) > 0 || len(alignErr.Mismatched) > 0 {
+		return aligned, alignErr
+	}
+	return aligned, nil
+}
+
+func containsHash(hashes []ethcommon.Hash, hash ethcommon.Hash) bool {
+	for _, h := range hashes {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
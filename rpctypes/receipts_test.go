@@ -0,0 +1,204 @@
+package rpctypes
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+func TestAlignReceipts(t *testing.T) {
+	blockHash := "0x00000000000000000000000000000000000000000000000000000000000000ff"
+	block := NewPolyBlock(&RawBlockResponse{
+		Hash: RawData32Response(blockHash),
+		Transactions: []RawTransactionResponse{
+			{Hash: "0x01"}, {Hash: "0x02"}, {Hash: "0x03"},
+		},
+	})
+
+	receipt := func(hash, index, blockHash string) *json.RawMessage {
+		raw := json.RawMessage(`{"transactionHash": "` + hash + `", "transactionIndex": "` + index + `", "blockHash": "` + blockHash + `"}`)
+		return &raw
+	}
+
+	// The batch came back shuffled.
+	receipts, err := AlignReceipts(block, []*json.RawMessage{
+		receipt("0x03", "0x2", blockHash),
+		receipt("0x01", "0x0", blockHash),
+		receipt("0x02", "0x1", blockHash),
+	})
+	if err != nil {
+		t.Fatalf("could not align receipts: %v", err)
+	}
+	if len(receipts) != 3 {
+		t.Fatalf("expected 3 receipts, got %d", len(receipts))
+	}
+	for idx, hash := range []string{"0x01", "0x02", "0x03"} {
+		if r := receipts[idx]; r.TransactionIndex() != uint64(idx) || r.TransactionHash() != ethcommon.HexToHash(hash) {
+			t.Errorf("expected receipt %d to be for transaction %s, got %s at index %d", idx, hash, r.TransactionHash(), r.TransactionIndex())
+		}
+	}
+
+	// The receipt for 0x02 is missing, 0x03 is from another block, and 0x04
+	// isn't in the block at all.
+	null := json.RawMessage("null")
+	receipts, err = AlignReceipts(block, []*json.RawMessage{
+		receipt("0x04", "0x3", blockHash),
+		receipt("0x03", "0x2", "0x00000000000000000000000000000000000000000000000000000000000000ee"),
+		&null,
+		receipt("0x01", "0x0", blockHash),
+	})
+	var alignErr *ReceiptAlignmentError
+	if !errors.As(err, &alignErr) {
+		t.Fatalf("expected an alignment error, got %v", err)
+	}
+	if len(alignErr.Missing) != 1 || alignErr.Missing[0] != ethcommon.HexToHash("0x02") {
+		t.Errorf("expected 0x02 to be missing, got %v", alignErr.Missing)
+	}
+	if len(alignErr.Unexpected) != 1 || alignErr.Unexpected[0] != ethcommon.HexToHash("0x04") {
+		t.Errorf("expected 0x04 to be unexpected, got %v", alignErr.Unexpected)
+	}
+	if len(alignErr.Mismatched) != 1 || alignErr.Mismatched[0] != ethcommon.HexToHash("0x03") {
+		t.Errorf("expected 0x03 to be mismatched, got %v", alignErr.Mismatched)
+	}
+	if len(receipts) != 3 || receipts[0] == nil || receipts[1] != nil || receipts[2] != nil {
+		t.Errorf("expected only the first receipt to be aligned, got %v", receipts)
+	}
+}
+
+func TestReceiptLogs(t *testing.T) {
+	transfer := "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+	logs := `[{
+		"address": "0x00000000000000000000000000000000000000aa",
+		"topics": ["` + transfer + `", "0x0000000000000000000000000000000000000000000000000000000000000001"],
+		"data": "0x00000000000000000000000000000000000000000000000000000000000003e8",
+		"blockNumber": "0x10",
+		"transactionHash": "0x00000000000000000000000000000000000000000000000000000000000000ff",
+		"transactionIndex": "0x2",
+		"blockHash": "0x00000000000000000000000000000000000000000000000000000000000000ee",
+		"logIndex": "0x5",
+		"removed": false
+	}, {
+		"address": "0x00000000000000000000000000000000000000bb",
+		"topics": [],
+		"data": "0x",
+		"blockNumber": "0x10",
+		"transactionHash": "0x00000000000000000000000000000000000000000000000000000000000000ff",
+		"transactionIndex": "0x2",
+		"blockHash": "0x00000000000000000000000000000000000000000000000000000000000000ee",
+		"logIndex": "0x6",
+		"removed": false
+	}]`
+
+	raw := new(RawTxReceipt)
+	if err := json.Unmarshal([]byte(`{"logs": `+logs+`}`), raw); err != nil {
+		t.Fatalf("could not decode receipt: %v", err)
+	}
+	receipt := NewPolyReceipt(raw)
+
+	if decoded := receipt.DecodedLogs(); len(decoded) != 2 {
+		t.Fatalf("expected 2 decoded logs, got %d", len(decoded))
+	}
+
+	matched := receipt.LogsWithTopic0(ethcommon.HexToHash(transfer))
+	if len(matched) != 1 {
+		t.Fatalf("expected 1 transfer log, got %d", len(matched))
+	}
+	l := matched[0]
+	if l.Address != ethcommon.HexToAddress("0xaa") || len(l.Topics) != 2 || len(l.Data) != 32 ||
+		l.BlockNumber != 16 || l.TxIndex != 2 || l.Index != 5 || l.TxHash != ethcommon.HexToHash("0xff") {
+		t.Errorf("unexpected decoded log %+v", l)
+	}
+
+	// Re-encoding the logs gives back what the node returned.
+	encoded, err := json.Marshal(raw.Logs)
+	if err != nil {
+		t.Fatalf("could not encode logs: %v", err)
+	}
+	got, err := CanonicalJSON(json.RawMessage(encoded))
+	if err != nil {
+		t.Fatalf("could not canonicalize logs: %v", err)
+	}
+	expected, err := CanonicalJSON(json.RawMessage(logs))
+	if err != nil {
+		t.Fatalf("could not canonicalize logs: %v", err)
+	}
+	if string(got) != string(expected) {
+		t.Errorf("expected logs to round trip, got %s", got)
+	}
+}
+
+func TestReceiptJSON(t *testing.T) {
+	data := `{"transactionHash": "0x00000000000000000000000000000000000000000000000000000000000000ff", "contractAddress": null, "status": "0x1", "logs": []}`
+	raw := new(RawTxReceipt)
+	if err := json.Unmarshal([]byte(data), raw); err != nil {
+		t.Fatalf("could not decode receipt: %v", err)
+	}
+	receipt := NewPolyReceipt(raw)
+	if receipt.ContractAddress() != (ethcommon.Address{}) {
+		t.Errorf("expected a zero contract address, got %s", receipt.ContractAddress())
+	}
+
+	encoded, err := json.Marshal(receipt)
+	if err != nil {
+		t.Fatalf("could not encode receipt: %v", err)
+	}
+	decoded := new(RawTxReceipt)
+	if err = json.Unmarshal(encoded, decoded); err != nil {
+		t.Fatalf("could not decode encoded receipt: %v", err)
+	}
+	if NewPolyReceipt(decoded).TransactionHash() != receipt.TransactionHash() || decoded.Status != "0x1" {
+		t.Errorf("expected the receipt to round trip, got %s", encoded)
+	}
+	if receipt.String() != string(encoded) {
+		t.Errorf("expected String to match the JSON encoding, got %s", receipt.String())
+	}
+}
+
+func TestContractCreation(t *testing.T) {
+	type test struct {
+		name     string
+		tx       string
+		receipt  string
+		creation bool
+		contract ethcommon.Address
+	}
+
+	tests := []test{
+		{
+			name:     "creation",
+			tx:       `{"to": null, "input": "0x6080"}`,
+			receipt:  `{"to": null, "contractAddress": "0x00000000000000000000000000000000000000cc"}`,
+			creation: true,
+			contract: ethcommon.HexToAddress("0xcc"),
+		},
+		{
+			name:    "transfer to the zero address",
+			tx:      `{"to": "0x0000000000000000000000000000000000000000", "value": "0x1"}`,
+			receipt: `{"to": "0x0000000000000000000000000000000000000000", "contractAddress": null}`,
+		},
+	}
+
+	for _, tc := range tests {
+		rawTx := new(RawTransactionResponse)
+		if err := json.Unmarshal([]byte(tc.tx), rawTx); err != nil {
+			t.Fatalf("%s: could not decode transaction: %v", tc.name, err)
+		}
+		rawReceipt := new(RawTxReceipt)
+		if err := json.Unmarshal([]byte(tc.receipt), rawReceipt); err != nil {
+			t.Fatalf("%s: could not decode receipt: %v", tc.name, err)
+		}
+
+		tx, receipt := NewPolyTransaction(rawTx), NewPolyReceipt(rawReceipt)
+		if tx.To() != (ethcommon.Address{}) {
+			t.Errorf("%s: expected the zero address recipient, got %s", tc.name, tx.To())
+		}
+		if tx.IsContractCreation() != tc.creation {
+			t.Errorf("%s: expected IsContractCreation to be %t", tc.name, tc.creation)
+		}
+		if receipt.ContractAddress() != tc.contract {
+			t.Errorf("%s: expected contract address %s, got %s", tc.name, tc.contract, receipt.ContractAddress())
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package rpctypes
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+// RedactedPlaceholder replaces the value of redacted string fields.
+const RedactedPlaceholder = "redacted"
+
+// identityFields are never redacted, so a redacted block or transaction can
+// still be identified and matched with its receipts.
+var identityFields = map[string]struct{}{
+	"hash":            {},
+	"transactionHash": {},
+	"blockHash":       {},
+	"number":          {},
+	"blockNumber":     {},
+}
+
+// Redactor redacts fields from blocks, transactions and receipts so dumps can
+// be shared without their sensitive payloads. Fields are redacted by JSON key,
+// e.g. input, and addresses by value wherever they appear as a field. String
+// values are replaced with RedactedPlaceholder and other values with null, so
+// the output still parses.
+type Redactor struct {
+	fields    map[string]struct{}
+	addresses map[string]struct{}
+}
+
+// NewRedactor creates a redactor from a list of JSON field names and hex
+// addresses. The hash and block number fields identify the data and can't be
+// redacted.
+func NewRedactor(items []string) (*Redactor, error) {
+	r := &Redactor{
+		fields:    make(map[string]struct{}),
+		addresses: make(map[string]struct{}),
+	}
+	for _, item := range items {
+		if ethcommon.IsHexAddress(item) {
+			r.addresses[strings.ToLower(ethcommon.HexToAddress(item).Hex())] = struct{}{}
+			continue
+		}
+		if _, ok := identityFields[item]; ok {
+			return nil, fmt.Errorf("the %s field identifies the data and can't be redacted", item)
+		}
+		if item == "" || strings.HasPrefix(item, "0x") {
+			return nil, fmt.Errorf("invalid field or address to redact: %q", item)
+		}
+		r.fields[item] = struct{}{}
+	}
+	return r, nil
+}
+
+// RedactBlock redacts the block's fields and those of its transactions, if the
+// block has full transaction objects.
+func (r *Redactor) RedactBlock(raw json.RawMessage) (json.RawMessage, error) {
+	fields, err := r.redact(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var txs []json.RawMessage
+	if err = json.Unmarshal(fields["transactions"], &txs); err == nil && len(txs) > 0 && !isJSONString(txs[0]) {
+		for idx := range txs {
+			if txs[idx], err = r.RedactObject(txs[idx]); err != nil {
+				return nil, err
+			}
+		}
+		if fields["transactions"], err = json.Marshal(txs); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(fields)
+}
+
+// RedactObject redacts the top level fields of a single JSON object, such as a
+// transaction or a receipt.
+func (r *Redactor) RedactObject(raw json.RawMessage) (json.RawMessage, error) {
+	fields, err := r.redact(raw)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+func (r *Redactor) redact(raw json.RawMessage) (map[string]json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, fmt.Errorf("unable to decode object to redact: %w", err)
+	}
+
+	for key, value := range fields {
+		if _, ok := identityFields[key]; ok {
+			continue
+		}
+		if _, ok := r.fields[key]; ok || r.isRedactedAddress(value) {
+			fields[key] = redactedValue(value)
+		}
+	}
+	return fields, nil
+}
+
+func (r *Redactor) isRedactedAddress(value json.RawMessage) bool {
+	if len(r.addresses) == 0 || !isJSONString(value) {
+		return false
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return false
+	}
+	_, ok := r.addresses[strings.ToLower(s)]
+	return ok
+}
+
+func redactedValue(value json.RawMessage) json.RawMessage {
+	if isJSONString(value) {
+		return json.RawMessage(`"` + RedactedPlaceholder + `"`)
+	}
+	return json.RawMessage("null")
+}
+
+func isJSONString(value json.RawMessage) bool {
+	v := strings.TrimSpace(string(value))
+	return strings.HasPrefix(v, `"`)
+}
@@ -0,0 +1,62 @@
+package rpctypes
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRedactor(t *testing.T) {
+	if _, err := NewRedactor([]string{"hash"}); err == nil {
+		t.Errorf("expected an error redacting the hash")
+	}
+
+	secret := "0x00000000000000000000000000000000000000aa"
+	r, err := NewRedactor([]string{"input", "0x00000000000000000000000000000000000000AA"})
+	if err != nil {
+		t.Fatalf("could not create redactor: %v", err)
+	}
+
+	block := []byte(`{
+		"number": "0x1",
+		"hash": "0x01",
+		"miner": "` + secret + `",
+		"transactions": [
+			{"hash": "0x02", "from": "` + secret + `", "to": "0x00000000000000000000000000000000000000bb", "input": "0xa9059cbb", "accessList": []},
+			{"hash": "0x03", "from": "0x00000000000000000000000000000000000000cc", "input": "0x"}
+		]
+	}`)
+	out, err := r.RedactBlock(block)
+	if err != nil {
+		t.Fatalf("could not redact block: %v", err)
+	}
+
+	raw := new(RawBlockResponse)
+	if err = json.Unmarshal(out, raw); err != nil {
+		t.Fatalf("redacted block doesn't parse: %v", err)
+	}
+	if raw.Hash != "0x01" || raw.Number != "0x1" || raw.Miner != RedactedPlaceholder {
+		t.Errorf("unexpected redacted block fields %s", out)
+	}
+	if len(raw.Transactions) != 2 {
+		t.Fatalf("expected 2 transactions, got %d", len(raw.Transactions))
+	}
+	tx := raw.Transactions[0]
+	if tx.Hash != "0x02" || tx.From != RedactedPlaceholder || tx.Input != RedactedPlaceholder || tx.To != "0x00000000000000000000000000000000000000bb" {
+		t.Errorf("unexpected redacted transaction %+v", tx)
+	}
+	if raw.Transactions[1].Input != RedactedPlaceholder || raw.Transactions[1].From == RedactedPlaceholder {
+		t.Errorf("unexpected redacted transaction %+v", raw.Transactions[1])
+	}
+
+	receipt, err := r.RedactObject([]byte(`{"transactionHash": "0x02", "from": "` + secret + `", "logs": []}`))
+	if err != nil {
+		t.Fatalf("could not redact receipt: %v", err)
+	}
+	parsed := new(RawTxReceipt)
+	if err = json.Unmarshal(receipt, parsed); err != nil {
+		t.Fatalf("redacted receipt doesn't parse: %v", err)
+	}
+	if parsed.TransactionHash != "0x02" || parsed.From != RedactedPlaceholder {
+		t.Errorf("unexpected redacted receipt %s", receipt)
+	}
+}
@@ -0,0 +1,46 @@
+package rpctypes
+
+import (
+	"fmt"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// The EIP-7685 execution layer request types.
+const (
+	DepositRequestType       byte = 0x00
+	WithdrawalRequestType    byte = 0x01
+	ConsolidationRequestType byte = 0x02
+)
+
+// BlockRequest is an EIP-7685 execution layer request, such as a deposit,
+// withdrawal or consolidation, made by the block's transactions.
+type BlockRequest struct {
+	Type byte
+	Data []byte
+}
+
+// RequestsHash returns the commitment to the block's execution layer
+// requests. Blocks from before Prague return the zero hash.
+func (i *implPolyBlock) RequestsHash() ethcommon.Hash {
+	return i.inner.RequestsHash.ToHash()
+}
+
+// Requests decodes the block's execution layer requests, if the client
+// returned them. Blocks from before Prague, and clients that only return the
+// requests hash, return an empty slice. A malformed request is an error.
+func (i *implPolyBlock) Requests() ([]BlockRequest, error) {
+	requests := make([]BlockRequest, 0, len(i.inner.Requests))
+	for idx, raw := range i.inner.Requests {
+		data, err := hexutil.Decode(string(raw))
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode execution layer request %d: %w", idx, err)
+		}
+		if len(data) == 0 {
+			return nil, fmt.Errorf("execution layer request %d has no type", idx)
+		}
+		requests = append(requests, BlockRequest{Type: data[0], Data: data[1:]})
+	}
+	return requests, nil
+}
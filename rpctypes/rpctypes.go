@@ -1,15 +1,22 @@
 package rpctypes
 
 import (
+	"bytes"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
-	// ethtypes "github.com/ethereum/go-ethereum/core/types"
 	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
 
 	"github.com/rs/zerolog/log"
 )
@@ -18,6 +25,49 @@ type (
 	SortableBlocks []PolyBlock
 )
 
+var (
+	// transferSelector is the selector of transfer(address,uint256).
+	transferSelector = []byte{0xa9, 0x05, 0x9c, 0xbb}
+
+	// tokenTransferSelectors are the selectors of the common ERC-20 and ERC-721
+	// transfer functions.
+	tokenTransferSelectors = map[string]struct{}{
+		string(transferSelector):               {}, // transfer(address,uint256)
+		string([]byte{0x23, 0xb8, 0x72, 0xdd}): {}, // transferFrom(address,address,uint256)
+		string([]byte{0x42, 0x84, 0x2e, 0x0e}): {}, // safeTransferFrom(address,address,uint256)
+		string([]byte{0xb8, 0x8d, 0x4f, 0xde}): {}, // safeTransferFrom(address,address,uint256,bytes)
+	}
+
+	// MaxDataBytes caps the decoded size of data fields, such as the input of
+	// a transaction or the extra data of a block, so a hostile endpoint can't
+	// exhaust memory with a huge response. It's far above anything a real
+	// block contains. Zero or less disables the cap.
+	MaxDataBytes = 64 << 20
+
+	// ErrDataTooLarge is returned when a data field is over MaxDataBytes.
+	ErrDataTooLarge = errors.New("data too large")
+
+	// ErrBlockNotFound is returned when the node doesn't know the requested
+	// block, i.e. it returned null.
+	ErrBlockNotFound = errors.New("block not found")
+
+	// emptyBlockJSONSize and emptyTxJSONSize are the JSON sizes of a block
+	// and a transaction with every field empty, i.e. the keys and punctuation.
+	emptyBlockJSONSize = jsonSize(RawBlockResponse{})
+	emptyTxJSONSize    = jsonSize(RawTransactionResponse{})
+)
+
+const (
+	// blockFixedJSONBytes is the encoded length of the fixed size block
+	// fields: seven hashes, the logs bloom, the miner and the nonce.
+	blockFixedJSONBytes = 7*(2+2*ethcommon.HashLength) + 2 + 2*ethtypes.BloomByteLength + 2 + 2*ethcommon.AddressLength + 2 + 2*8
+
+	// txTypicalJSONBytes is the encoded length of a typical transaction's
+	// fields excluding its input: four hashes and signature values, two
+	// addresses and a handful of short quantities.
+	txTypicalJSONBytes = 4*(2+2*ethcommon.HashLength) + 2*(2+2*ethcommon.AddressLength) + 100
+)
+
 func (a SortableBlocks) Len() int {
 	return len(a)
 }
@@ -28,6 +78,130 @@ func (a SortableBlocks) Less(i, j int) bool {
 	return a[i].Number().Int64() < a[j].Number().Int64()
 }
 
+// Dedup returns the transactions with duplicate hashes removed, keeping the
+// first occurrence of each so the original order is preserved.
+func (txs PolyTransactions) Dedup() PolyTransactions {
+	seen := make(map[ethcommon.Hash]struct{}, len(txs))
+	deduped := make(PolyTransactions, 0, len(txs))
+	for _, tx := range txs {
+		hash := tx.Hash()
+		if _, ok := seen[hash]; ok {
+			continue
+		}
+		seen[hash] = struct{}{}
+		deduped = append(deduped, tx)
+	}
+	return deduped
+}
+
+// DedupTransactions collects the transactions of a range of blocks, such as
+// one merged from overlapping fetches, with duplicates removed as in Dedup. It
+// also returns how many duplicates were removed.
+func DedupTransactions(blocks []PolyBlock) (PolyTransactions, int) {
+	var all PolyTransactions
+	for _, b := range blocks {
+		all = append(all, b.Transactions()...)
+	}
+	deduped := all.Dedup()
+	return deduped, len(all) - len(deduped)
+}
+
+// GroupBySender buckets the transactions by their sender. The decoded address
+// is the key, so senders match regardless of the case of the hex in the
+// response. Each sender's transactions keep their original order.
+func (txs PolyTransactions) GroupBySender() map[ethcommon.Address]PolyTransactions {
+	groups := make(map[ethcommon.Address]PolyTransactions)
+	for _, tx := range txs {
+		from := tx.From()
+		groups[from] = append(groups[from], tx)
+	}
+	return groups
+}
+
+// GroupByBlock regroups a flat list of transactions, such as a dump that
+// wasn't grouped by block, into per block lists keyed by block number. Each
+// block's transactions are sorted by their transaction index. If any of a
+// block's transactions is missing its index, the block's transactions keep
+// their original order instead.
+func (txs PolyTransactions) GroupByBlock() map[uint64]PolyTransactions {
+	groups := make(map[uint64]PolyTransactions)
+	for _, tx := range txs {
+		number := tx.BlockNumber().Uint64()
+		groups[number] = append(groups[number], tx)
+	}
+
+	for _, group := range groups {
+		indexed := true
+		for _, tx := range group {
+			if _, ok := tx.TransactionIndex(); !ok {
+				indexed = false
+				break
+			}
+		}
+		if !indexed {
+			continue
+		}
+		sort.SliceStable(group, func(a, b int) bool {
+			x, _ := group[a].TransactionIndex()
+			y, _ := group[b].TransactionIndex()
+			return x < y
+		})
+	}
+	return groups
+}
+
+// TotalGasUsed returns the gas used by all of the blocks.
+func (bs PolyBlocks) TotalGasUsed() uint64 {
+	var total uint64
+	for _, b := range bs {
+		total += b.GasUsed()
+	}
+	return total
+}
+
+// TotalTransactions returns the number of transactions in all of the blocks.
+func (bs PolyBlocks) TotalTransactions() int {
+	total := 0
+	for _, b := range bs {
+		total += b.TransactionCount()
+	}
+	return total
+}
+
+// AverageGasUtilization returns the fraction of the blocks' combined gas limit
+// that was used, so larger blocks weigh more. No blocks, or blocks without a
+// gas limit, return zero.
+func (bs PolyBlocks) AverageGasUtilization() float64 {
+	var limit uint64
+	for _, b := range bs {
+		limit += b.GasLimit()
+	}
+	if limit == 0 {
+		return 0
+	}
+	return float64(bs.TotalGasUsed()) / float64(limit)
+}
+
+// TimeSpan returns the number of seconds between the earliest and latest block
+// timestamps. The blocks don't need to be sorted. Fewer than two blocks return
+// zero.
+func (bs PolyBlocks) TimeSpan() uint64 {
+	if len(bs) == 0 {
+		return 0
+	}
+	earliest, latest := bs[0].Time(), bs[0].Time()
+	for _, b := range bs[1:] {
+		t := b.Time()
+		if t < earliest {
+			earliest = t
+		}
+		if t > latest {
+			latest = t
+		}
+	}
+	return latest - earliest
+}
+
 type (
 	RawQuantityResponse string
 	RawDataResponse     string
@@ -88,9 +262,24 @@ type (
 		// EIP 2718 Type field?
 		Type RawQuantityResponse `json:"type"`
 
+		// chainId: QUANTITY - the chain the transaction is valid on. Absent for legacy transactions from before EIP-155.
 		ChainID RawQuantityResponse `json:"chainId"`
 
-		AccessList []any `json:"accessList"`
+		// accessList: Array - the addresses and storage keys the transaction
+		// plans to access, for EIP-2930 and later transaction types.
+		AccessList []RawAccessTuple `json:"accessList"`
+
+		// maxFeePerBlobGas: QUANTITY - the most the sender pays per unit of blob gas. EIP-4844 blob transactions only.
+		MaxFeePerBlobGas RawQuantityResponse `json:"maxFeePerBlobGas,omitempty"`
+
+		// blobVersionedHashes: Array - the versioned hashes of the blobs. EIP-4844 blob transactions only.
+		BlobVersionedHashes []RawData32Response `json:"blobVersionedHashes,omitempty"`
+	}
+
+	// RawAccessTuple is an entry of a transaction's access list.
+	RawAccessTuple struct {
+		Address     RawData20Response   `json:"address"`
+		StorageKeys []RawData32Response `json:"storageKeys"`
 	}
 
 	RawBlockResponse struct {
@@ -148,6 +337,10 @@ type (
 		// transactions: Array - Array of transaction objects, or 32 Bytes transaction hashes depending on the last given parameter.
 		Transactions []RawTransactionResponse `json:"transactions"`
 
+		// TransactionHashes is set instead of Transactions when the block was
+		// requested without full transaction objects.
+		TransactionHashes []RawData32Response `json:"-"`
+
 		// uncles: Array - Array of uncle hashes.
 		Uncles []RawData32Response `json:"uncles"`
 
@@ -156,6 +349,42 @@ type (
 
 		// receiptsRoot: DATA, 32 Bytes - a 256-bit hash encoded as a hexadecimal
 		MixHash RawData32Response `json:"mixHash"`
+
+		// withdrawalsRoot: DATA, 32 Bytes - the root of the withdrawals trie. Shanghai and later.
+		WithdrawalsRoot RawData32Response `json:"withdrawalsRoot,omitempty"`
+
+		// withdrawals: Array - the validator withdrawals processed by the block. Shanghai and later.
+		Withdrawals []RawWithdrawal `json:"withdrawals,omitempty"`
+
+		// blobGasUsed: QUANTITY - the total blob gas used by the block. Cancun and later.
+		BlobGasUsed RawQuantityResponse `json:"blobGasUsed,omitempty"`
+
+		// excessBlobGas: QUANTITY - the running total of blob gas above the target. Cancun and later.
+		ExcessBlobGas RawQuantityResponse `json:"excessBlobGas,omitempty"`
+
+		// parentBeaconBlockRoot: DATA, 32 Bytes - the root of the parent beacon block. Cancun and later.
+		ParentBeaconBlockRoot RawData32Response `json:"parentBeaconBlockRoot,omitempty"`
+
+		// requestsHash: DATA, 32 Bytes - the commitment to the execution layer requests. Prague and later.
+		RequestsHash RawData32Response `json:"requestsHash,omitempty"`
+
+		// requests: Array of DATA - the execution layer requests, each prefixed with its type. Prague and later, when the client returns them.
+		Requests []RawDataResponse `json:"requests,omitempty"`
+	}
+
+	// RawWithdrawal is a validator withdrawal processed by a block.
+	RawWithdrawal struct {
+		// index: QUANTITY - the index of the withdrawal across all blocks.
+		Index RawQuantityResponse `json:"index"`
+
+		// validatorIndex: QUANTITY - the index of the withdrawing validator.
+		ValidatorIndex RawQuantityResponse `json:"validatorIndex"`
+
+		// address: DATA, 20 Bytes - the recipient of the withdrawn amount.
+		Address RawData20Response `json:"address"`
+
+		// amount: QUANTITY - the amount withdrawn, in Gwei.
+		Amount RawQuantityResponse `json:"amount"`
 	}
 
 	RawTxLogs struct {
@@ -231,25 +460,53 @@ type (
 		Status RawQuantityResponse `json:"status"`
 	}
 
+	// The Poly* interfaces wrap the raw JSON-RPC responses with decoded
+	// accessors. Fields that are null or missing in the response, e.g. for
+	// pending blocks and transactions, decode to zero values: numeric accessors
+	// return zero (a non-nil *big.Int), hash and address accessors return the
+	// zero hash and zero address, and byte accessors return an empty slice.
+	// The exceptions are the accessors whose absence means something, which
+	// return nil: PolyTransaction.ChainID for legacy transactions from before
+	// EIP-155, PolyTransaction.MaxFeePerBlobGas for transactions that aren't
+	// blob transactions, and PolyBlock.BlobBaseFee for blocks from before
+	// Cancun.
 	PolyTransaction interface {
 		GasPrice() *big.Int
 		Hash() ethcommon.Hash
 		To() ethcommon.Address
+		IsContractCreation() bool
 		From() ethcommon.Address
 		Data() []byte
 		Value() *big.Int
 		Gas() uint64
 		Nonce() uint64
 		String() string
+		ChecksumString() string
 		MarshalJSON() ([]byte, error)
+		MarshalJSONDecoded() ([]byte, error)
 		Type() uint64
-		MaxPriorityFeePerGas() uint64
-		MaxFeePerGas() uint64
-		ChainID() uint64
+		TxType() uint8
+		Validate() error
+		MaxPriorityFeePerGas() *big.Int
+		MaxFeePerGas() *big.Int
+		ChainID() *big.Int
+		AccessList() ethtypes.AccessList
+		MaxFeePerBlobGas() *big.Int
+		BlobVersionedHashes() []ethcommon.Hash
 		BlockNumber() *big.Int
+		TransactionIndex() (uint64, bool)
 		V() *big.Int
 		R() *big.Int
 		S() *big.Int
+		ToEthTransaction() (*ethtypes.Transaction, error)
+		RawBytes() ([]byte, error)
+		RecoverSender(chainID *big.Int, verify bool) (ethcommon.Address, error)
+		CalldataGas() uint64
+		IsLikelyTokenTransfer() bool
+		TokenTransfer() (ethcommon.Address, *big.Int, bool)
+		Confirmations(head *big.Int) uint64
+		MethodSelector() ([4]byte, bool)
+		MethodSelectorHex() string
 	}
 	PolyTransactions []PolyTransaction
 
@@ -265,9 +522,14 @@ type (
 		GasUsed() *big.Int
 		ContractAddress() ethcommon.Address
 		Logs() []RawTxLogs
+		DecodedLogs() []*ethtypes.Log
+		LogsWithTopic0(topic ethcommon.Hash) []*ethtypes.Log
 		LogsBloom() []byte
 		Root() ethcommon.Hash
 		Status() uint64
+		String() string
+		MarshalJSON() ([]byte, error)
+		MarshalJSONDecoded() ([]byte, error)
 	}
 	PolyReceipts []PolyReceipt
 	PolyBlock    interface {
@@ -290,12 +552,41 @@ type (
 		Nonce() uint64
 		String() string
 		MarshalJSON() ([]byte, error)
+		MarshalJSONDecoded() ([]byte, error)
 		ReceiptsRoot() ethcommon.Hash
 		LogsBloom() []byte
+		CalldataGas() uint64
+		AveragePriorityFee(receipts PolyReceipts) *big.Int
+		FailedTransactions(receipts PolyReceipts) []ethcommon.Hash
+		FailureRate(receipts PolyReceipts) float64
+		TransactionHashes() []ethcommon.Hash
+		EstimatedStorageBytes() int
+		MinerTransactions() (int, uint64, *big.Int)
+		EncodeHeaderRLP() ([]byte, error)
+		ComputeHash() (ethcommon.Hash, error)
+		GasTarget(elasticity uint64) uint64
+		GasUtilization() float64
+		IsEmpty() bool
+		RequestsHash() ethcommon.Hash
+		Requests() ([]BlockRequest, error)
+		WithdrawalsRoot() ethcommon.Hash
+		Withdrawals() ethtypes.Withdrawals
+		BurnedFees() *big.Int
+		BlobGasUsed() uint64
+		ExcessBlobGas() uint64
+		BlobBaseFee() *big.Int
+		TransactionCount() int
+		ContentHash() ethcommon.Hash
 	}
+	PolyBlocks []PolyBlock
 
 	implPolyBlock struct {
 		inner *RawBlockResponse
+
+		// The quantities read in per transaction loops are decoded once.
+		number     cachedBigInt
+		difficulty cachedBigInt
+		baseFee    cachedBigInt
 	}
 	implPolyTransaction struct {
 		inner *RawTransactionResponse
@@ -315,7 +606,9 @@ func (i *implPolyReceipt) BlockNumber() *big.Int {
 	return i.inner.BlockNumber.ToBigInt()
 }
 
-// ContractAddress implements PolyReceipt.
+// ContractAddress implements PolyReceipt. Receipts of transactions that
+// didn't create a contract, where the node returns null, return the zero
+// address. See PolyTransaction.IsContractCreation.
 func (i *implPolyReceipt) ContractAddress() ethcommon.Address {
 	return i.inner.ContractAddress.ToAddress()
 }
@@ -375,11 +668,48 @@ func (i *implPolyReceipt) TransactionIndex() uint64 {
 	return i.inner.TransactionIndex.ToUint64()
 }
 
+// String implements PolyReceipt.
+func (i *implPolyReceipt) String() string {
+	d, err := json.Marshal(i)
+	if err != nil {
+		panic(err)
+	}
+	return string(d)
+}
+
+// MarshalJSON implements PolyReceipt. The receipt is encoded as the node
+// returned it.
+func (i *implPolyReceipt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.inner)
+}
+
+// NewPolyBlock wraps the raw block. Some of its quantities are decoded once on
+// first access and cached, so the raw block must not be modified afterwards.
 func NewPolyBlock(r *RawBlockResponse) PolyBlock {
 	i := new(implPolyBlock)
 	i.inner = r
 	return i
 }
+
+// NewPolyBlockFromJSON decodes the raw result of eth_getBlockByNumber or
+// eth_getBlockByHash, whose transactions are either full objects or hashes
+// depending on how the block was requested. If fullTxs is set, a block with
+// only the transaction hashes is an error. A null result, for a block the node
+// doesn't have, is ErrBlockNotFound.
+func NewPolyBlockFromJSON(data []byte, fullTxs bool) (PolyBlock, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return nil, ErrBlockNotFound
+	}
+
+	raw := new(RawBlockResponse)
+	if err := json.Unmarshal(data, raw); err != nil {
+		return nil, fmt.Errorf("unable to decode block: %w", err)
+	}
+	if fullTxs && len(raw.TransactionHashes) > 0 {
+		return nil, fmt.Errorf("block %s has only transaction hashes, it must be requested with full transactions", raw.Hash.ToHash())
+	}
+	return NewPolyBlock(raw), nil
+}
 func NewPolyTransaction(r *RawTransactionResponse) PolyTransaction {
 	i := new(implPolyTransaction)
 	i.inner = r
@@ -392,13 +722,28 @@ func NewPolyReceipt(r *RawTxReceipt) PolyReceipt {
 }
 
 func (i *implPolyBlock) Number() *big.Int {
-	return i.inner.Number.ToBigInt()
+	return i.number.get(&i.inner.Number)
 }
 func (i *implPolyBlock) Difficulty() *big.Int {
-	return i.inner.Difficulty.ToBigInt()
+	return i.difficulty.get(&i.inner.Difficulty)
 }
 func (i *implPolyBlock) BaseFee() *big.Int {
-	return i.inner.BaseFeePerGas.ToBigInt()
+	return i.baseFee.get(&i.inner.BaseFeePerGas)
+}
+
+// cachedBigInt decodes a quantity on first use and is safe for concurrent use.
+type cachedBigInt struct {
+	once  sync.Once
+	value *big.Int
+}
+
+// get returns a copy of the decoded quantity, so callers can still modify the
+// result like they could with ToBigInt.
+func (c *cachedBigInt) get(r *RawQuantityResponse) *big.Int {
+	c.once.Do(func() {
+		c.value = r.ToBigInt()
+	})
+	return new(big.Int).Set(c.value)
 }
 
 func (i *implPolyBlock) Time() uint64 {
@@ -453,6 +798,96 @@ func (i *implPolyBlock) ReceiptsRoot() ethcommon.Hash {
 func (i *implPolyBlock) LogsBloom() []byte {
 	return i.inner.LogsBloom.ToBytes()
 }
+
+// CalldataGas is the sum of the calldata gas of every transaction in the block.
+// This approximates the L1 data availability cost of posting the block.
+func (i *implPolyBlock) CalldataGas() uint64 {
+	var total uint64
+	for _, tx := range i.Transactions() {
+		total += tx.CalldataGas()
+	}
+	return total
+}
+
+// AveragePriorityFee returns the average priority fee paid per unit of gas in
+// the block, weighted by gas. The receipts are used for the exact effective gas
+// price and gas used of each transaction. If a transaction's receipt isn't
+// provided, its priority fee is estimated from the fee caps and weighted by
+// its gas limit instead. Empty blocks return zero.
+func (i *implPolyBlock) AveragePriorityFee(receipts PolyReceipts) *big.Int {
+	byHash := make(map[ethcommon.Hash]PolyReceipt, len(receipts))
+	for _, r := range receipts {
+		byHash[r.TransactionHash()] = r
+	}
+
+	baseFee := i.BaseFee()
+	total := new(big.Int)
+	totalGas := new(big.Int)
+	for _, tx := range i.Transactions() {
+		var fee, gas *big.Int
+		if r, ok := byHash[tx.Hash()]; ok {
+			fee = new(big.Int).Sub(r.EffectiveGasPrice(), baseFee)
+			gas = r.GasUsed()
+		} else {
+			fee = estimatePriorityFee(tx, baseFee)
+			gas = new(big.Int).SetUint64(tx.Gas())
+		}
+		if fee.Sign() < 0 {
+			fee.SetInt64(0)
+		}
+
+		total.Add(total, fee.Mul(fee, gas))
+		totalGas.Add(totalGas, gas)
+	}
+
+	if totalGas.Sign() == 0 {
+		return new(big.Int)
+	}
+	return total.Div(total, totalGas)
+}
+
+// FailedTransactions returns the hashes of the transactions in the block that
+// failed. The block doesn't carry execution results, so this requires the
+// receipts of the block's transactions. Transactions without a receipt are
+// ignored. Pre-Byzantium receipts have a post-state root instead of a status,
+// so for those a transaction is considered failed if it used all of its gas.
+func (i *implPolyBlock) FailedTransactions(receipts PolyReceipts) []ethcommon.Hash {
+	byHash := make(map[ethcommon.Hash]PolyReceipt, len(receipts))
+	for _, r := range receipts {
+		byHash[r.TransactionHash()] = r
+	}
+
+	failed := make([]ethcommon.Hash, 0)
+	for _, tx := range i.Transactions() {
+		r, ok := byHash[tx.Hash()]
+		if !ok {
+			continue
+		}
+
+		if r.Root() != (ethcommon.Hash{}) {
+			if r.GasUsed().Cmp(new(big.Int).SetUint64(tx.Gas())) == 0 {
+				failed = append(failed, tx.Hash())
+			}
+			continue
+		}
+
+		if r.Status() == 0 {
+			failed = append(failed, tx.Hash())
+		}
+	}
+
+	return failed
+}
+
+// FailureRate returns the fraction of the block's transactions that failed,
+// using FailedTransactions. Empty blocks return zero.
+func (i *implPolyBlock) FailureRate(receipts PolyReceipts) float64 {
+	total := len(i.inner.Transactions)
+	if total == 0 {
+		return 0
+	}
+	return float64(len(i.FailedTransactions(receipts))) / float64(total)
+}
 func (i *implPolyBlock) String() string {
 	d, err := json.Marshal(i)
 	if err != nil {
@@ -464,26 +899,292 @@ func (i *implPolyBlock) MarshalJSON() ([]byte, error) {
 	return json.Marshal(i.inner)
 }
 
+// TransactionCount returns the number of transactions in the block, whether
+// the block has full transaction objects or only their hashes, without
+// decoding them.
+func (i *implPolyBlock) TransactionCount() int {
+	if len(i.inner.TransactionHashes) > 0 {
+		return len(i.inner.TransactionHashes)
+	}
+	return len(i.inner.Transactions)
+}
+
+// TransactionHashes returns the hashes of the block's transactions. It works
+// for blocks fetched with or without full transaction objects, whereas
+// Transactions returns an empty slice for hashes-only blocks.
+func (i *implPolyBlock) TransactionHashes() []ethcommon.Hash {
+	if len(i.inner.TransactionHashes) > 0 {
+		hashes := make([]ethcommon.Hash, len(i.inner.TransactionHashes))
+		for idx, h := range i.inner.TransactionHashes {
+			hashes[idx] = h.ToHash()
+		}
+		return hashes
+	}
+
+	hashes := make([]ethcommon.Hash, len(i.inner.Transactions))
+	for idx := range i.inner.Transactions {
+		hashes[idx] = i.inner.Transactions[idx].Hash.ToHash()
+	}
+	return hashes
+}
+
+// GasTarget returns the EIP-1559 gas target of the block, which is the gas
+// limit divided by the elasticity multiplier of the chain, e.g.
+// params.DefaultElasticityMultiplier on Ethereum. The base fee of the next
+// block rises when the gas used is above the target and falls when it's below.
+// Blocks from before London, which don't have a base fee, and a zero
+// elasticity return zero.
+func (i *implPolyBlock) GasTarget(elasticity uint64) uint64 {
+	if i.inner.BaseFeePerGas == "" || elasticity == 0 {
+		return 0
+	}
+	return i.GasLimit() / elasticity
+}
+
+// GasUtilization returns the fraction of the gas limit the block used, between
+// 0 and 1. Blocks without a gas limit return zero.
+func (i *implPolyBlock) GasUtilization() float64 {
+	if i.GasLimit() == 0 {
+		return 0
+	}
+	return float64(i.GasUsed()) / float64(i.GasLimit())
+}
+
+// IsEmpty reports whether the block has no transactions.
+func (i *implPolyBlock) IsEmpty() bool {
+	return i.TransactionCount() == 0
+}
+
+// BurnedFees returns the fees burned by the block under EIP-1559, which is the
+// base fee times the gas used. Blocks from before London return zero.
+func (i *implPolyBlock) BurnedFees() *big.Int {
+	return new(big.Int).Mul(i.BaseFee(), new(big.Int).SetUint64(i.GasUsed()))
+}
+
+// BlobGasUsed returns the blob gas used by the block's blob transactions.
+// Blocks from before Cancun return zero.
+func (i *implPolyBlock) BlobGasUsed() uint64 {
+	return i.inner.BlobGasUsed.ToUint64()
+}
+
+// ExcessBlobGas returns the blob gas used in excess of the target, which sets
+// the blob base fee. Blocks from before Cancun return zero.
+func (i *implPolyBlock) ExcessBlobGas() uint64 {
+	return i.inner.ExcessBlobGas.ToUint64()
+}
+
+// BlobBaseFee derives the price per unit of blob gas from the excess blob gas
+// as specified by EIP-4844. Blocks from before Cancun don't have a blob fee
+// market and return nil.
+func (i *implPolyBlock) BlobBaseFee() *big.Int {
+	if i.inner.ExcessBlobGas == "" {
+		return nil
+	}
+	return eip4844.CalcBlobFee(i.ExcessBlobGas())
+}
+
+// MinerTransactions finds the transactions in the block sent by the block's
+// miner, which reveals builder payments and self dealing. It returns their
+// count, total gas limit and total value, which are all zero if the miner
+// didn't send any.
+func (i *implPolyBlock) MinerTransactions() (int, uint64, *big.Int) {
+	miner := i.Miner()
+	count, gas, value := 0, uint64(0), new(big.Int)
+	for _, tx := range i.Transactions() {
+		if tx.From() != miner {
+			continue
+		}
+		count++
+		gas += tx.Gas()
+		value.Add(value, tx.Value())
+	}
+	return count, gas, value
+}
+
+// EstimatedStorageBytes approximates the size of the block serialized as JSON,
+// without marshaling it, for capacity planning. Fixed size fields are counted
+// at their encoded length and each transaction at a typical size plus its
+// input data, which is usually within 10% of the real size.
+func (i *implPolyBlock) EstimatedStorageBytes() int {
+	b := i.inner
+	size := emptyBlockJSONSize + blockFixedJSONBytes + len(b.ExtraData)
+	for _, q := range []RawQuantityResponse{b.Number, b.Difficulty, b.TotalDifficulty, b.Size, b.GasLimit, b.GasUsed, b.Timestamp, b.BaseFeePerGas} {
+		size += len(q)
+	}
+	size += len(b.Uncles) * (2 + 2*ethcommon.HashLength + 3)
+	size += len(b.TransactionHashes) * (2 + 2*ethcommon.HashLength + 3)
+	for idx := range b.Transactions {
+		size += emptyTxJSONSize + txTypicalJSONBytes + len(b.Transactions[idx].Input)
+	}
+	return size
+}
+
+// rawBlockResponse has the same fields as RawBlockResponse without its JSON
+// methods, so they can use the default encoding for everything else.
+type rawBlockResponse RawBlockResponse
+
+// UnmarshalJSON decodes the transactions array as either full transaction
+// objects or transaction hashes, depending on how the block was requested.
+// Hashes are stored in TransactionHashes rather than as empty transactions.
+func (r *RawBlockResponse) UnmarshalJSON(data []byte) error {
+	aux := struct {
+		*rawBlockResponse
+		Transactions json.RawMessage `json:"transactions"`
+	}{rawBlockResponse: (*rawBlockResponse)(r)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	r.Transactions = nil
+	r.TransactionHashes = nil
+	txs := bytes.TrimSpace(aux.Transactions)
+	if len(txs) == 0 || bytes.Equal(txs, []byte("null")) {
+		return nil
+	}
+
+	var elems []json.RawMessage
+	if err := json.Unmarshal(txs, &elems); err != nil {
+		return err
+	}
+	if len(elems) > 0 && bytes.HasPrefix(bytes.TrimSpace(elems[0]), []byte(`"`)) {
+		return json.Unmarshal(txs, &r.TransactionHashes)
+	}
+	return json.Unmarshal(txs, &r.Transactions)
+}
+
+// MarshalJSON encodes the block, writing TransactionHashes as the transactions
+// array for hashes-only blocks so they round trip.
+func (r RawBlockResponse) MarshalJSON() ([]byte, error) {
+	if len(r.Transactions) == 0 && len(r.TransactionHashes) > 0 {
+		return json.Marshal(struct {
+			rawBlockResponse
+			Transactions []RawData32Response `json:"transactions"`
+		}{rawBlockResponse: rawBlockResponse(r), Transactions: r.TransactionHashes})
+	}
+	return json.Marshal(rawBlockResponse(r))
+}
+
 func (i *implPolyTransaction) GasPrice() *big.Int {
 	return i.inner.GasPrice.ToBigInt()
 }
 func (i *implPolyTransaction) BlockNumber() *big.Int {
 	return i.inner.BlockNumber.ToBigInt()
 }
+
+// TransactionIndex returns the transaction's position in its block. Pending
+// transactions don't have one, so false is returned when it's missing.
+func (i *implPolyTransaction) TransactionIndex() (uint64, bool) {
+	if i.inner.TransactionIndex == "" {
+		return 0, false
+	}
+	return i.inner.TransactionIndex.ToUint64(), true
+}
+
+// MethodSelector returns the 4 byte selector of the function the transaction
+// calls, which is the first four bytes of its input. Value transfers with less
+// than four bytes of input and contract creations, whose input is init code,
+// don't have a selector and return a zero selector and false.
+func (i *implPolyTransaction) MethodSelector() ([4]byte, bool) {
+	var selector [4]byte
+	if i.inner.To == "" {
+		return selector, false
+	}
+	data := i.Data()
+	if len(data) < len(selector) {
+		return selector, false
+	}
+	copy(selector[:], data)
+	return selector, true
+}
+
+// MethodSelectorHex returns the method selector as a 0x prefixed hex string,
+// or an empty string if the transaction doesn't have one.
+func (i *implPolyTransaction) MethodSelectorHex() string {
+	selector, ok := i.MethodSelector()
+	if !ok {
+		return ""
+	}
+	return "0x" + hex.EncodeToString(selector[:])
+}
+
+// Confirmations returns how many blocks, including its own, the transaction
+// has been confirmed by given the current head block number. Pending
+// transactions, and heads below the transaction's block, return zero.
+func (i *implPolyTransaction) Confirmations(head *big.Int) uint64 {
+	if i.inner.BlockNumber == "" || head == nil {
+		return 0
+	}
+	confirmations := new(big.Int).Sub(head, i.BlockNumber())
+	if confirmations.Sign() < 0 {
+		return 0
+	}
+	return confirmations.Uint64() + 1
+}
 func (i *implPolyTransaction) Gas() uint64 {
 	return i.inner.Gas.ToUint64()
 }
-func (i *implPolyTransaction) MaxPriorityFeePerGas() uint64 {
-	return i.inner.MaxPriorityFeePerGas.ToUint64()
+
+// MaxPriorityFeePerGas returns the tip of a dynamic fee transaction. Legacy
+// transactions don't have one, so their gas price is returned instead.
+func (i *implPolyTransaction) MaxPriorityFeePerGas() *big.Int {
+	if i.inner.MaxPriorityFeePerGas == "" {
+		return i.GasPrice()
+	}
+	return i.inner.MaxPriorityFeePerGas.ToBigInt()
 }
-func (i *implPolyTransaction) MaxFeePerGas() uint64 {
-	return i.inner.MaxFeePerGas.ToUint64()
+
+// MaxFeePerGas returns the fee cap of a dynamic fee transaction. Legacy
+// transactions don't have one, so their gas price is returned instead.
+func (i *implPolyTransaction) MaxFeePerGas() *big.Int {
+	if i.inner.MaxFeePerGas == "" {
+		return i.GasPrice()
+	}
+	return i.inner.MaxFeePerGas.ToBigInt()
 }
 func (i *implPolyTransaction) Nonce() uint64 {
 	return i.inner.Nonce.ToUint64()
 }
-func (i *implPolyTransaction) ChainID() uint64 {
-	return i.inner.ChainID.ToUint64()
+
+// ChainID returns the chain the transaction is pinned to, or nil for legacy
+// transactions from before EIP-155 that don't specify one.
+func (i *implPolyTransaction) ChainID() *big.Int {
+	if i.inner.ChainID == "" {
+		return nil
+	}
+	return i.inner.ChainID.ToBigInt()
+}
+
+// AccessList returns the addresses and storage keys the transaction declared
+// it would access. Transactions without an access list return an empty list.
+func (i *implPolyTransaction) AccessList() ethtypes.AccessList {
+	accessList := make(ethtypes.AccessList, 0, len(i.inner.AccessList))
+	for _, tuple := range i.inner.AccessList {
+		keys := make([]ethcommon.Hash, 0, len(tuple.StorageKeys))
+		for _, k := range tuple.StorageKeys {
+			keys = append(keys, k.ToHash())
+		}
+		accessList = append(accessList, ethtypes.AccessTuple{Address: tuple.Address.ToAddress(), StorageKeys: keys})
+	}
+	return accessList
+}
+
+// MaxFeePerBlobGas returns the blob gas fee cap of a blob transaction, or nil
+// for other transactions.
+func (i *implPolyTransaction) MaxFeePerBlobGas() *big.Int {
+	if i.inner.MaxFeePerBlobGas == "" {
+		return nil
+	}
+	return i.inner.MaxFeePerBlobGas.ToBigInt()
+}
+
+// BlobVersionedHashes returns the versioned hashes of the blobs a blob
+// transaction carries. Other transactions return an empty slice.
+func (i *implPolyTransaction) BlobVersionedHashes() []ethcommon.Hash {
+	hashes := make([]ethcommon.Hash, 0, len(i.inner.BlobVersionedHashes))
+	for _, h := range i.inner.BlobVersionedHashes {
+		hashes = append(hashes, h.ToHash())
+	}
+	return hashes
 }
 func (i *implPolyTransaction) Type() uint64 {
 	return i.inner.Type.ToUint64()
@@ -506,12 +1207,197 @@ func (i *implPolyTransaction) Hash() ethcommon.Hash {
 func (i *implPolyTransaction) To() ethcommon.Address {
 	return i.inner.To.ToAddress()
 }
+
+// IsContractCreation reports whether the transaction creates a contract, i.e.
+// its recipient was null or left out. A transaction sent to the zero address
+// isn't a creation even though To returns the zero address for both. The
+// address of the created contract is the ContractAddress of its receipt.
+func (i *implPolyTransaction) IsContractCreation() bool {
+	return i.inner.To.IsEmpty()
+}
 func (i *implPolyTransaction) From() ethcommon.Address {
 	return i.inner.From.ToAddress()
 }
 func (i *implPolyTransaction) Data() []byte {
 	return i.inner.Input.ToBytes()
 }
+
+// CalldataGas returns the gas charged for the transaction input when posted as
+// calldata, which is 4 gas per zero byte and 16 gas per non-zero byte.
+func (i *implPolyTransaction) CalldataGas() uint64 {
+	var gas uint64
+	for _, b := range i.Data() {
+		if b == 0 {
+			gas += params.TxDataZeroGas
+		} else {
+			gas += params.TxDataNonZeroGasEIP2028
+		}
+	}
+	return gas
+}
+
+// IsLikelyTokenTransfer reports whether the transaction calls one of the common
+// ERC-20 or ERC-721 transfer functions. This is only a heuristic based on the
+// function selector, so any contract exposing a function with the same
+// selector will match, and transfers done through other functions won't.
+func (i *implPolyTransaction) IsLikelyTokenTransfer() bool {
+	data := i.Data()
+	if len(data) < 4 {
+		return false
+	}
+	_, ok := tokenTransferSelectors[string(data[:4])]
+	return ok
+}
+
+// TokenTransfer decodes the recipient and amount of a transfer(address,uint256)
+// call. The boolean is false if the input isn't a well formed transfer call.
+func (i *implPolyTransaction) TokenTransfer() (ethcommon.Address, *big.Int, bool) {
+	data := i.Data()
+	if len(data) != 4+32+32 || !bytes.Equal(data[:4], transferSelector) {
+		return ethcommon.Address{}, nil, false
+	}
+	recipient := ethcommon.BytesToAddress(data[4:36])
+	amount := new(big.Int).SetBytes(data[36:68])
+	return recipient, amount, true
+}
+
+// ToEthTransaction rebuilds the signed go-ethereum transaction from the raw
+// response so it can be re-encoded or re-hashed. Unsigned pseudo transactions,
+// such as the Polygon state sync transactions, can't be represented and will
+// return an error.
+func (i *implPolyTransaction) ToEthTransaction() (*ethtypes.Transaction, error) {
+	v, r, s := i.V(), i.R(), i.S()
+	if v.Sign() == 0 && r.Sign() == 0 && s.Sign() == 0 {
+		return nil, fmt.Errorf("transaction %s is unsigned", i.Hash())
+	}
+
+	var to *ethcommon.Address
+	if !i.IsContractCreation() {
+		addr := i.To()
+		to = &addr
+	}
+
+	accessList := i.AccessList()
+
+	var inner ethtypes.TxData
+	switch i.Type() {
+	case ethtypes.LegacyTxType:
+		inner = &ethtypes.LegacyTx{
+			Nonce:    i.Nonce(),
+			GasPrice: i.GasPrice(),
+			Gas:      i.Gas(),
+			To:       to,
+			Value:    i.Value(),
+			Data:     i.Data(),
+			V:        v,
+			R:        r,
+			S:        s,
+		}
+	case ethtypes.AccessListTxType:
+		inner = &ethtypes.AccessListTx{
+			ChainID:    i.inner.ChainID.ToBigInt(),
+			Nonce:      i.Nonce(),
+			GasPrice:   i.GasPrice(),
+			Gas:        i.Gas(),
+			To:         to,
+			Value:      i.Value(),
+			Data:       i.Data(),
+			AccessList: accessList,
+			V:          v,
+			R:          r,
+			S:          s,
+		}
+	case ethtypes.DynamicFeeTxType:
+		inner = &ethtypes.DynamicFeeTx{
+			ChainID:    i.inner.ChainID.ToBigInt(),
+			Nonce:      i.Nonce(),
+			GasTipCap:  i.inner.MaxPriorityFeePerGas.ToBigInt(),
+			GasFeeCap:  i.inner.MaxFeePerGas.ToBigInt(),
+			Gas:        i.Gas(),
+			To:         to,
+			Value:      i.Value(),
+			Data:       i.Data(),
+			AccessList: accessList,
+			V:          v,
+			R:          r,
+			S:          s,
+		}
+	case ethtypes.BlobTxType:
+		if to == nil {
+			return nil, fmt.Errorf("blob transaction %s has no recipient", i.Hash())
+		}
+		// The blob transaction fields are 256-bit words, so larger values can't
+		// be represented.
+		values := make([]*uint256.Int, 0, 8)
+		for _, value := range []*big.Int{
+			i.inner.ChainID.ToBigInt(), i.inner.MaxPriorityFeePerGas.ToBigInt(), i.inner.MaxFeePerGas.ToBigInt(),
+			i.Value(), i.inner.MaxFeePerBlobGas.ToBigInt(), v, r, s,
+		} {
+			word, overflow := uint256.FromBig(value)
+			if overflow {
+				return nil, fmt.Errorf("blob transaction %s has a value over 256 bits", i.Hash())
+			}
+			values = append(values, word)
+		}
+		inner = &ethtypes.BlobTx{
+			ChainID:    values[0],
+			Nonce:      i.Nonce(),
+			GasTipCap:  values[1],
+			GasFeeCap:  values[2],
+			Gas:        i.Gas(),
+			To:         *to,
+			Value:      values[3],
+			Data:       i.Data(),
+			AccessList: accessList,
+			BlobFeeCap: values[4],
+			BlobHashes: i.BlobVersionedHashes(),
+			V:          values[5],
+			R:          values[6],
+			S:          values[7],
+		}
+	default:
+		return nil, fmt.Errorf("unsupported transaction type %d", i.Type())
+	}
+
+	return ethtypes.NewTx(inner), nil
+}
+
+// RawBytes returns the canonical encoding of the signed transaction, which is
+// what eth_sendRawTransaction takes to rebroadcast it and what its hash is
+// computed over. Legacy transactions are plain RLP and typed transactions are
+// the type byte followed by the RLP payload. Transactions that ToEthTransaction
+// can't rebuild, such as unsigned pseudo transactions, return an error.
+func (i *implPolyTransaction) RawBytes() ([]byte, error) {
+	tx, err := i.ToEthTransaction()
+	if err != nil {
+		return nil, err
+	}
+	return tx.MarshalBinary()
+}
+
+// RecoverSender recovers the sender from the transaction's signature rather
+// than trusting the from field the node returned. The signing hash depends on
+// the type and chain ID, so if chainID is nil the transaction's own chain ID
+// is used. If verify is set, a sender that doesn't match the from field is an
+// error, which means the node returned inconsistent transaction data.
+func (i *implPolyTransaction) RecoverSender(chainID *big.Int, verify bool) (ethcommon.Address, error) {
+	tx, err := i.ToEthTransaction()
+	if err != nil {
+		return ethcommon.Address{}, err
+	}
+	if chainID == nil {
+		chainID = i.ChainID()
+	}
+
+	sender, err := ethtypes.Sender(ethtypes.LatestSignerForChainID(chainID), tx)
+	if err != nil {
+		return ethcommon.Address{}, fmt.Errorf("unable to recover the sender of transaction %s: %w", i.Hash(), err)
+	}
+	if verify && sender != i.From() {
+		return sender, fmt.Errorf("transaction %s was signed by %s, not its reported sender %s", i.Hash(), sender, i.From())
+	}
+	return sender, nil
+}
 func (i *implPolyTransaction) String() string {
 	d, err := json.Marshal(i)
 	if err != nil {
@@ -519,10 +1405,71 @@ func (i *implPolyTransaction) String() string {
 	}
 	return string(d)
 }
+
+// ChecksumString is like String, but with the from and to addresses in their
+// EIP-55 checksummed form, as block explorers show them, instead of the
+// node's casing.
+func (i *implPolyTransaction) ChecksumString() string {
+	tx := *i.inner
+	tx.From = RawData20Response(tx.From.ToChecksumString())
+	tx.To = RawData20Response(tx.To.ToChecksumString())
+	d, err := json.Marshal(tx)
+	if err != nil {
+		panic(err)
+	}
+	return string(d)
+}
 func (i *implPolyTransaction) MarshalJSON() ([]byte, error) {
 	return json.Marshal(i.inner)
 }
 
+// estimatePriorityFee estimates the priority fee per gas of a transaction
+// without its receipt. This is the tip capped by the headroom between the fee
+// cap and the base fee, which for legacy transactions, whose tip and fee cap
+// are their gas price, is the gas price above the base fee. The result is
+// never negative.
+func estimatePriorityFee(tx PolyTransaction, baseFee *big.Int) *big.Int {
+	fee := tx.MaxPriorityFeePerGas()
+	if headroom := new(big.Int).Sub(tx.MaxFeePerGas(), baseFee); headroom.Cmp(fee) < 0 {
+		fee = headroom
+	}
+
+	if fee.Sign() < 0 {
+		return new(big.Int)
+	}
+	return fee
+}
+
+// EstimateEffectiveGasPrice returns the best available estimate of the gas
+// price the transaction paid per unit of gas. There are three tiers of
+// accuracy, depending on what's available:
+//
+//  1. If the receipt is given and has an effective gas price, it's exact.
+//  2. Otherwise, with the block's base fee known, it's the base fee plus the
+//     tip, capped at the fee cap. This matches the protocol, but relies on the
+//     base fee being right. For legacy transactions it's their gas price.
+//  3. Otherwise it's the transaction's gas price, which is exact for legacy
+//     transactions and what most nodes report for mined dynamic fee ones.
+//
+// The receipt may be nil and the base fee may be nil or zero.
+func EstimateEffectiveGasPrice(tx PolyTransaction, baseFee *big.Int, receipt PolyReceipt) *big.Int {
+	if receipt != nil {
+		if price := receipt.EffectiveGasPrice(); price.Sign() > 0 {
+			return price
+		}
+	}
+
+	if tx.MaxFeePerGas().Sign() == 0 || baseFee == nil || baseFee.Sign() == 0 {
+		return tx.GasPrice()
+	}
+
+	price := new(big.Int).Add(baseFee, tx.MaxPriorityFeePerGas())
+	if maxFee := tx.MaxFeePerGas(); price.Cmp(maxFee) > 0 {
+		return maxFee
+	}
+	return price
+}
+
 // HexToBigInt assumes that it's input is a hex encoded string and
 // will try to convert it to a big int
 func ConvHexToBigInt(raw any) (bi *big.Int, err error) {
@@ -531,9 +1478,9 @@ func ConvHexToBigInt(raw any) (bi *big.Int, err error) {
 	if err != nil {
 		return nil, err
 	}
-	hexString = strings.Replace(hexString, "0x", "", -1)
-	if len(hexString)%2 != 0 {
-		hexString = "0" + hexString
+	hexString, err = ParseHexString(hexString)
+	if err != nil {
+		return nil, err
 	}
 
 	rawGas, err := hex.DecodeString(hexString)
@@ -576,9 +1523,9 @@ func ConvHexToUint64(raw any) (uint64, error) {
 		return 0, err
 	}
 
-	hexString = strings.Replace(hexString, "0x", "", -1)
-	if len(hexString)%2 != 0 {
-		hexString = "0" + hexString
+	hexString, err = ParseHexString(hexString)
+	if err != nil {
+		return 0, err
 	}
 
 	result, err := strconv.ParseUint(hexString, 16, 64)
@@ -596,23 +1543,66 @@ func MustConvHexToUint64(raw any) uint64 {
 	return num
 }
 
-func NewRawBlockResponseFromAny(raw any) (*RawBlockResponse, error) {
-	topMap, ok := raw.(map[string]any)
-	if !ok {
-		return nil, fmt.Errorf("unable to map raw response")
+// ParseHexString validates and normalizes a hex string from an RPC response or
+// messy input like a CSV file or a flag. Surrounding whitespace and a single
+// 0x or 0X prefix are removed, the digits are lowercased, and odd length
+// strings are padded with a leading zero. Strings with an embedded prefix or
+// other non hex characters return an error rather than being misparsed.
+func ParseHexString(s string) (string, error) {
+	hexString := strings.TrimSpace(s)
+	if strings.HasPrefix(hexString, "0x") || strings.HasPrefix(hexString, "0X") {
+		hexString = hexString[2:]
 	}
-	_ = topMap
-	return nil, nil
-
+	hexString = strings.ToLower(hexString)
+	for idx, c := range hexString {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return "", fmt.Errorf("invalid hex string %q: unexpected character %q at %d", s, c, idx)
+		}
+	}
+	if len(hexString)%2 != 0 {
+		hexString = "0" + hexString
+	}
+	return hexString, nil
 }
 
+// normalizeHexString is the lenient form of ParseHexString used by the Raw*
+// conversions, which decode invalid hex to zero values. Invalid strings
+// normalize to an empty string.
 func normalizeHexString(s string) string {
-	hexString := strings.Replace(s, "0x", "", -1)
-	if len(hexString)%2 != 0 {
-		hexString = "0" + hexString
+	hexString, err := ParseHexString(s)
+	if err != nil {
+		return ""
 	}
 	return hexString
 }
+
+// IsEmpty reports whether the quantity is the empty string, e.g. the block
+// number of a pending transaction. A JSON null, a missing field and an
+// explicit "" all decode to the empty string, so it can't tell them apart, but
+// it does tell them from a real zero, "0x0". The conversions still return zero
+// for it.
+func (r RawQuantityResponse) IsEmpty() bool { return r == "" }
+
+// IsEmpty reports whether the data is the empty string. See
+// RawQuantityResponse.IsEmpty.
+func (r RawDataResponse) IsEmpty() bool { return r == "" }
+
+// IsEmpty reports whether the data is the empty string. See
+// RawQuantityResponse.IsEmpty.
+func (r RawData8Response) IsEmpty() bool { return r == "" }
+
+// IsEmpty reports whether the address is the empty string, e.g. the recipient
+// of a contract creation. See RawQuantityResponse.IsEmpty.
+func (r RawData20Response) IsEmpty() bool { return r == "" }
+
+// IsEmpty reports whether the hash is the empty string, e.g. the block hash of
+// a pending transaction. See RawQuantityResponse.IsEmpty.
+func (r RawData32Response) IsEmpty() bool { return r == "" }
+
+// IsEmpty reports whether the data is the empty string. See
+// RawQuantityResponse.IsEmpty.
+func (r RawData256Response) IsEmpty() bool { return r == "" }
+
 func (r RawData8Response) ToUint64() uint64 {
 	hexString := normalizeHexString(string(r))
 	result, err := strconv.ParseUint(hexString, 16, 64)
@@ -644,25 +1634,100 @@ func (r RawQuantityResponse) ToInt64() int64 {
 
 }
 
+func jsonSize(v any) int {
+	d, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return len(d)
+}
+
+// ToBigInt decodes the quantity. Null, empty, or malformed quantities decode
+// to a non-nil zero so the result is always safe to use in arithmetic.
 func (r *RawQuantityResponse) ToBigInt() *big.Int {
 	hexString := normalizeHexString(string(*r))
-	bi := new(big.Int)
-	bi.SetString(hexString, 16)
+	bi, ok := new(big.Int).SetString(hexString, 16)
+	if !ok {
+		return new(big.Int)
+	}
 	return bi
 }
+
+// ToBigIntChecked decodes the quantity like ToBigInt, but returns an error
+// with the raw string if it's malformed, e.g. has non-hex characters or no
+// digits, instead of decoding it to zero. Null and empty quantities, for
+// fields the node left out, still decode to zero.
+func (r *RawQuantityResponse) ToBigIntChecked() (*big.Int, error) {
+	if *r == "" {
+		return new(big.Int), nil
+	}
+	hexString, err := ParseHexString(string(*r))
+	if err != nil {
+		return nil, fmt.Errorf("invalid quantity %q: %w", string(*r), err)
+	}
+	bi, ok := new(big.Int).SetString(hexString, 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid quantity %q", string(*r))
+	}
+	return bi, nil
+}
+
+// ToUint256 decodes the quantity like ToBigIntChecked, but also returns an
+// error if it doesn't fit in the EVM's 256-bit word, i.e. it's negative or
+// larger than 2^256-1, so corrupt or oversized responses are caught early.
+func (r *RawQuantityResponse) ToUint256() (*big.Int, error) {
+	bi, err := r.ToBigIntChecked()
+	if err != nil {
+		return nil, err
+	}
+	if bi.Sign() < 0 {
+		return nil, fmt.Errorf("quantity %q is negative", string(*r))
+	}
+	if bi.BitLen() > 256 {
+		return nil, fmt.Errorf("quantity %q overflows 256 bits: has %d bits", string(*r), bi.BitLen())
+	}
+	return bi, nil
+}
+
+// ToGwei converts the quantity from wei to gwei. Null and empty quantities
+// convert to zero.
+func (r *RawQuantityResponse) ToGwei() *big.Float {
+	return weiToUnit(r.ToBigInt(), params.GWei)
+}
+
+// ToEther converts the quantity from wei to ether. Null and empty quantities
+// convert to zero.
+func (r *RawQuantityResponse) ToEther() *big.Float {
+	return weiToUnit(r.ToBigInt(), params.Ether)
+}
+
+// weiToUnit divides the wei amount by the unit, e.g. params.GWei, as a
+// big.Float with at least the precision of the amount.
+func weiToUnit(wei *big.Int, unit float64) *big.Float {
+	return new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(unit))
+}
 func (r *RawQuantityResponse) String() string {
 	return r.ToBigInt().String()
 }
 
 func (r *RawData20Response) ToAddress() ethcommon.Address {
-	return ethcommon.HexToAddress(string(*r))
+	return ethcommon.HexToAddress(normalizeHexString(string(*r)))
+}
+
+// ToChecksumString returns the address in its EIP-55 mixed-case checksummed
+// form. Empty addresses, such as the recipient of a contract creation, stay
+// empty.
+func (r *RawData20Response) ToChecksumString() string {
+	if *r == "" {
+		return ""
+	}
+	return r.ToAddress().Hex()
 }
 func (r *RawData32Response) ToHash() ethcommon.Hash {
-	return ethcommon.HexToHash(string(*r))
+	return ethcommon.HexToHash(normalizeHexString(string(*r)))
 }
 func (r *RawDataResponse) ToBytes() []byte {
-	hexString := normalizeHexString(string(*r))
-	data, err := hex.DecodeString(hexString)
+	data, err := r.Decode()
 	if err != nil {
 		log.Error().Err(err).Msg("Unable to convert raw data to bytes")
 		return nil
@@ -670,12 +1735,56 @@ func (r *RawDataResponse) ToBytes() []byte {
 	return data
 }
 
+// Decode decodes the data, returning an error if it isn't valid hex or if it
+// would decode to more than MaxDataBytes. The size is checked before
+// decoding so an oversized response doesn't allocate.
+func (r *RawDataResponse) Decode() ([]byte, error) {
+	return decodeHexData(string(*r))
+}
+
 func (r *RawData256Response) ToBytes() []byte {
-	hexString := normalizeHexString(string(*r))
-	data, err := hex.DecodeString(hexString)
+	data, err := decodeHexData(string(*r))
 	if err != nil {
 		log.Error().Err(err).Msg("Unable to convert raw data to bytes")
 		return nil
 	}
 	return data
 }
+
+// Contains reports whether the data, such as a log address or topic, might be
+// in the logs bloom, using the three bit positions from its keccak256 hash.
+// Like any bloom filter it can have false positives, but never false
+// negatives, so a missing or malformed bloom might contain anything.
+func (r RawData256Response) Contains(data []byte) bool {
+	if r.IsEmpty() {
+		return true
+	}
+	bloom := r.ToBytes()
+	if len(bloom) != ethtypes.BloomByteLength {
+		return true
+	}
+	return ethtypes.BytesToBloom(bloom).Test(data)
+}
+
+// ContainsAddress reports whether logs emitted by the address might be in the
+// logs bloom. See Contains.
+func (r RawData256Response) ContainsAddress(address ethcommon.Address) bool {
+	return r.Contains(address.Bytes())
+}
+
+// ContainsTopic reports whether logs with the topic might be in the logs
+// bloom. See Contains.
+func (r RawData256Response) ContainsTopic(topic ethcommon.Hash) bool {
+	return r.Contains(topic.Bytes())
+}
+
+func decodeHexData(s string) ([]byte, error) {
+	hexString, err := ParseHexString(s)
+	if err != nil {
+		return nil, err
+	}
+	if MaxDataBytes > 0 && len(hexString)/2 > MaxDataBytes {
+		return nil, fmt.Errorf("%w: %d bytes is over the limit of %d", ErrDataTooLarge, len(hexString)/2, MaxDataBytes)
+	}
+	return hex.DecodeString(hexString)
+}
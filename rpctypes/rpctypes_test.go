@@ -0,0 +1,1239 @@
+package rpctypes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+func TestCalldataGas(t *testing.T) {
+	// 2 zero bytes and 3 non-zero bytes.
+	tx := NewPolyTransaction(&RawTransactionResponse{Input: "0x0000a9059c"})
+	if gas := tx.CalldataGas(); gas != 2*4+3*16 {
+		t.Errorf("expected calldata gas %d, got %d", 2*4+3*16, gas)
+	}
+
+	empty := NewPolyTransaction(&RawTransactionResponse{Input: "0x"})
+	if gas := empty.CalldataGas(); gas != 0 {
+		t.Errorf("expected calldata gas 0 for empty input, got %d", gas)
+	}
+
+	block := NewPolyBlock(&RawBlockResponse{
+		Transactions: []RawTransactionResponse{{Input: "0x0000a9059c"}, {Input: "0x01"}},
+	})
+	if gas := block.CalldataGas(); gas != 2*4+3*16+16 {
+		t.Errorf("expected block calldata gas %d, got %d", 2*4+3*16+16, gas)
+	}
+}
+
+func TestNullFieldsDecodeToZero(t *testing.T) {
+	block := NewPolyBlock(&RawBlockResponse{
+		Transactions: []RawTransactionResponse{{}},
+	})
+
+	blockInts := map[string]func() *big.Int{
+		"Number":     block.Number,
+		"Difficulty": block.Difficulty,
+		"BaseFee":    block.BaseFee,
+	}
+	for name, f := range blockInts {
+		if v := f(); v == nil || v.Sign() != 0 {
+			t.Errorf("expected block %s to be a non-nil zero, got %v", name, v)
+		}
+	}
+	if block.Hash() != (ethcommon.Hash{}) || block.Miner() != (ethcommon.Address{}) {
+		t.Errorf("expected zero hash and miner for a pending block")
+	}
+
+	tx := block.Transactions()[0]
+	txInts := map[string]func() *big.Int{
+		"GasPrice":    tx.GasPrice,
+		"Value":       tx.Value,
+		"BlockNumber": tx.BlockNumber,
+		"V":           tx.V,
+		"R":           tx.R,
+		"S":           tx.S,
+	}
+	for name, f := range txInts {
+		if v := f(); v == nil || v.Sign() != 0 {
+			t.Errorf("expected transaction %s to be a non-nil zero, got %v", name, v)
+		}
+	}
+	if tx.To() != (ethcommon.Address{}) || tx.From() != (ethcommon.Address{}) {
+		t.Errorf("expected zero addresses for a pending transaction")
+	}
+
+	receipt := NewPolyReceipt(&RawTxReceipt{})
+	receiptInts := map[string]func() *big.Int{
+		"BlockNumber":       receipt.BlockNumber,
+		"CumulativeGasUsed": receipt.CumulativeGasUsed,
+		"EffectiveGasPrice": receipt.EffectiveGasPrice,
+		"GasUsed":           receipt.GasUsed,
+	}
+	for name, f := range receiptInts {
+		if v := f(); v == nil || v.Sign() != 0 {
+			t.Errorf("expected receipt %s to be a non-nil zero, got %v", name, v)
+		}
+	}
+}
+
+func TestIsEmpty(t *testing.T) {
+	type test struct {
+		name  string
+		data  string
+		empty bool
+	}
+
+	// A null, a missing field and an empty string can't be told apart, but
+	// they can from a real zero.
+	tests := []test{
+		{name: "null", data: `{"blockHash": null, "blockNumber": null, "to": null, "input": null}`, empty: true},
+		{name: "missing", data: `{}`, empty: true},
+		{name: "empty string", data: `{"blockHash": "", "blockNumber": "", "to": "", "input": ""}`, empty: true},
+		{name: "zero", data: `{"blockHash": "0x0000000000000000000000000000000000000000000000000000000000000000",
+			"blockNumber": "0x0", "to": "0x0000000000000000000000000000000000000000", "input": "0x"}`},
+	}
+
+	for _, tc := range tests {
+		var tx RawTransactionResponse
+		if err := json.Unmarshal([]byte(tc.data), &tx); err != nil {
+			t.Fatalf("%s: could not decode transaction: %v", tc.name, err)
+		}
+		for field, empty := range map[string]bool{
+			"blockHash":   tx.BlockHash.IsEmpty(),
+			"blockNumber": tx.BlockNumber.IsEmpty(),
+			"to":          tx.To.IsEmpty(),
+			"input":       tx.Input.IsEmpty(),
+		} {
+			if empty != tc.empty {
+				t.Errorf("%s: expected %s to be empty %t, got %t", tc.name, field, tc.empty, empty)
+			}
+		}
+		if tx.BlockNumber.ToUint64() != 0 {
+			t.Errorf("%s: expected the block number to convert to 0", tc.name)
+		}
+	}
+}
+
+func TestToBigIntChecked(t *testing.T) {
+	type test struct {
+		raw      RawQuantityResponse
+		expected int64
+		fail     bool
+	}
+
+	tests := []test{
+		{raw: "0x1a", expected: 26},
+		{raw: "0X1A", expected: 26},
+		{raw: "", expected: 0},
+		{raw: "0x0", expected: 0},
+		{raw: "0x", fail: true},
+		{raw: "0x1g", fail: true},
+		{raw: "twelve", fail: true},
+	}
+
+	for _, tc := range tests {
+		bi, err := tc.raw.ToBigIntChecked()
+		if tc.fail {
+			if err == nil {
+				t.Errorf("%q: expected an error, got %s", tc.raw, bi)
+			} else if !strings.Contains(err.Error(), string(tc.raw)) {
+				t.Errorf("%q: expected the error to include the raw string, got %v", tc.raw, err)
+			}
+			continue
+		}
+		if err != nil || bi.Int64() != tc.expected {
+			t.Errorf("%q: expected %d, got %v (%v)", tc.raw, tc.expected, bi, err)
+		}
+	}
+
+	// The unchecked form still decodes malformed quantities to zero.
+	malformed := RawQuantityResponse("0x1g")
+	if bi := malformed.ToBigInt(); bi.Sign() != 0 {
+		t.Errorf("expected a malformed quantity to decode to zero, got %s", bi)
+	}
+}
+
+func TestToUint256(t *testing.T) {
+	type test struct {
+		raw  RawQuantityResponse
+		bits int
+		fail string
+	}
+
+	tests := []test{
+		{raw: "", bits: 0},
+		{raw: "0x1", bits: 1},
+		// 2^256-1 is the largest word.
+		{raw: RawQuantityResponse("0x" + strings.Repeat("f", 64)), bits: 256},
+		// 2^256 and above overflow.
+		{raw: RawQuantityResponse("0x1" + strings.Repeat("0", 64)), fail: "has 257 bits"},
+		{raw: RawQuantityResponse("0x" + strings.Repeat("f", 80)), fail: "has 320 bits"},
+		{raw: "-0x1", fail: "invalid quantity"},
+		{raw: "0x1g", fail: "invalid quantity"},
+	}
+
+	for _, tc := range tests {
+		bi, err := tc.raw.ToUint256()
+		if tc.fail != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.fail) {
+				t.Errorf("%q: expected an error containing %q, got %v (%v)", tc.raw, tc.fail, bi, err)
+			}
+			continue
+		}
+		if err != nil || bi.BitLen() != tc.bits {
+			t.Errorf("%q: expected %d bits, got %v (%v)", tc.raw, tc.bits, bi, err)
+		}
+	}
+}
+
+func TestToGweiAndEther(t *testing.T) {
+	type test struct {
+		raw   RawQuantityResponse
+		gwei  string
+		ether string
+	}
+
+	tests := []test{
+		{raw: "", gwei: "0", ether: "0"},
+		{raw: "0x1", gwei: "0.000000001", ether: "0.000000000000000001"},
+		// 1 gwei and 1 ether exactly.
+		{raw: "0x3b9aca00", gwei: "1", ether: "0.000000001"},
+		{raw: "0xde0b6b3a7640000", gwei: "1000000000", ether: "1"},
+		// 1 gwei plus 1 wei.
+		{raw: "0x3b9aca01", gwei: "1.000000001", ether: "0.000000001000000001"},
+	}
+
+	// Rounded to wei, without trailing zeros.
+	text := func(f *big.Float, decimals int) string {
+		return strings.TrimRight(strings.TrimRight(f.Text('f', decimals), "0"), ".")
+	}
+
+	for _, tc := range tests {
+		if gwei := text(tc.raw.ToGwei(), 9); gwei != tc.gwei {
+			t.Errorf("%q: expected %s gwei, got %s", tc.raw, tc.gwei, gwei)
+		}
+		if ether := text(tc.raw.ToEther(), 18); ether != tc.ether {
+			t.Errorf("%q: expected %s ether, got %s", tc.raw, tc.ether, ether)
+		}
+	}
+}
+
+func TestAveragePriorityFee(t *testing.T) {
+	raw := &RawBlockResponse{
+		BaseFeePerGas: "0xa",
+		Transactions: []RawTransactionResponse{
+			// Legacy transaction paying a gas price of 15.
+			{Hash: "0x01", Type: "0x0", GasPrice: "0xf", Gas: "0x5208"},
+			// Dynamic fee transaction with a tip of 2 and a fee cap of 30.
+			{Hash: "0x02", Type: "0x2", GasPrice: "0xc", MaxFeePerGas: "0x1e", MaxPriorityFeePerGas: "0x2", Gas: "0xc350"},
+		},
+	}
+	block := NewPolyBlock(raw)
+
+	// Without receipts the gas limit is used as the weight:
+	// (5*21000 + 2*50000) / 71000 = 2
+	if fee := block.AveragePriorityFee(nil); fee.Cmp(big.NewInt(2)) != 0 {
+		t.Errorf("expected estimated average priority fee 2, got %s", fee)
+	}
+
+	// With receipts the gas used is used as the weight:
+	// (5*21000 + 2*30000) / 51000 = 3
+	receipts := PolyReceipts{
+		NewPolyReceipt(&RawTxReceipt{TransactionHash: "0x01", EffectiveGasPrice: "0xf", GasUsed: "0x5208"}),
+		NewPolyReceipt(&RawTxReceipt{TransactionHash: "0x02", EffectiveGasPrice: "0xc", GasUsed: "0x7530"}),
+	}
+	if fee := block.AveragePriorityFee(receipts); fee.Cmp(big.NewInt(3)) != 0 {
+		t.Errorf("expected average priority fee 3, got %s", fee)
+	}
+
+	empty := NewPolyBlock(&RawBlockResponse{BaseFeePerGas: "0xa"})
+	if fee := empty.AveragePriorityFee(nil); fee.Sign() != 0 {
+		t.Errorf("expected zero average priority fee for an empty block, got %s", fee)
+	}
+}
+
+func TestTokenTransfer(t *testing.T) {
+	recipient := "000000000000000000000000ab5801a7d398351b8be11c439e05c5b3259aec9b"
+	amount := "00000000000000000000000000000000000000000000000000000000000003e8"
+
+	transfer := NewPolyTransaction(&RawTransactionResponse{Input: RawDataResponse("0xa9059cbb" + recipient + amount)})
+	if !transfer.IsLikelyTokenTransfer() {
+		t.Errorf("expected transfer call to be a likely token transfer")
+	}
+	to, value, ok := transfer.TokenTransfer()
+	if !ok {
+		t.Fatalf("expected transfer call to decode")
+	}
+	if to != ethcommon.HexToAddress("0xab5801a7d398351b8be11c439e05c5b3259aec9b") {
+		t.Errorf("unexpected recipient %s", to.Hex())
+	}
+	if value.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("expected amount 1000, got %s", value)
+	}
+
+	transferFrom := NewPolyTransaction(&RawTransactionResponse{Input: RawDataResponse("0x23b872dd" + recipient + recipient + amount)})
+	if !transferFrom.IsLikelyTokenTransfer() {
+		t.Errorf("expected transferFrom call to be a likely token transfer")
+	}
+	if _, _, ok := transferFrom.TokenTransfer(); ok {
+		t.Errorf("expected transferFrom call not to decode as transfer")
+	}
+
+	for _, input := range []RawDataResponse{"0x", "0xa905", "0x095ea7b3" + RawDataResponse(recipient+amount)} {
+		tx := NewPolyTransaction(&RawTransactionResponse{Input: input})
+		if tx.IsLikelyTokenTransfer() {
+			t.Errorf("expected %s not to be a likely token transfer", input)
+		}
+	}
+}
+
+func TestFailedTransactions(t *testing.T) {
+	block := NewPolyBlock(&RawBlockResponse{
+		Transactions: []RawTransactionResponse{
+			{Hash: "0x01", Gas: "0x5208"},
+			{Hash: "0x02", Gas: "0x5208"},
+			{Hash: "0x03", Gas: "0x5208"},
+			{Hash: "0x04", Gas: "0x5208"},
+		},
+	})
+	receipts := PolyReceipts{
+		NewPolyReceipt(&RawTxReceipt{TransactionHash: "0x01", Status: "0x1", GasUsed: "0x5208"}),
+		NewPolyReceipt(&RawTxReceipt{TransactionHash: "0x02", Status: "0x0", GasUsed: "0x5000"}),
+		// Pre-Byzantium receipts, the first used all of its gas.
+		NewPolyReceipt(&RawTxReceipt{TransactionHash: "0x03", Root: "0xaa", GasUsed: "0x5208"}),
+		NewPolyReceipt(&RawTxReceipt{TransactionHash: "0x04", Root: "0xaa", GasUsed: "0x5000"}),
+	}
+
+	failed := block.FailedTransactions(receipts)
+	expected := []ethcommon.Hash{ethcommon.HexToHash("0x02"), ethcommon.HexToHash("0x03")}
+	if len(failed) != len(expected) {
+		t.Fatalf("expected %d failed transactions, got %d", len(expected), len(failed))
+	}
+	for idx := range expected {
+		if failed[idx] != expected[idx] {
+			t.Errorf("expected failed transaction %s, got %s", expected[idx], failed[idx])
+		}
+	}
+
+	if rate := block.FailureRate(receipts); rate != 0.5 {
+		t.Errorf("expected failure rate 0.5, got %v", rate)
+	}
+	if rate := NewPolyBlock(&RawBlockResponse{}).FailureRate(nil); rate != 0 {
+		t.Errorf("expected failure rate 0 for an empty block, got %v", rate)
+	}
+}
+
+func TestHashesOnlyBlock(t *testing.T) {
+	data := []byte(`{"number":"0x10","transactions":["0x01","0x02"]}`)
+
+	raw := new(RawBlockResponse)
+	if err := json.Unmarshal(data, raw); err != nil {
+		t.Fatalf("could not decode block: %v", err)
+	}
+	block := NewPolyBlock(raw)
+
+	if txs := block.Transactions(); len(txs) != 0 {
+		t.Errorf("expected no transactions for a hashes-only block, got %d", len(txs))
+	}
+	hashes := block.TransactionHashes()
+	if len(hashes) != 2 || hashes[0] != ethcommon.HexToHash("0x01") || hashes[1] != ethcommon.HexToHash("0x02") {
+		t.Errorf("unexpected transaction hashes %v", hashes)
+	}
+	if block.Number().Uint64() != 16 {
+		t.Errorf("expected block number 16, got %s", block.Number())
+	}
+
+	out, err := json.Marshal(raw)
+	if err != nil {
+		t.Fatalf("could not encode block: %v", err)
+	}
+	roundTrip := new(RawBlockResponse)
+	if err := json.Unmarshal(out, roundTrip); err != nil {
+		t.Fatalf("could not decode encoded block: %v", err)
+	}
+	if len(roundTrip.TransactionHashes) != 2 {
+		t.Errorf("expected transaction hashes to round trip, got %s", out)
+	}
+
+	full := NewPolyBlock(&RawBlockResponse{Transactions: []RawTransactionResponse{{Hash: "0x03"}}})
+	if hashes := full.TransactionHashes(); len(hashes) != 1 || hashes[0] != ethcommon.HexToHash("0x03") {
+		t.Errorf("unexpected transaction hashes for a full block %v", hashes)
+	}
+}
+
+func TestNewPolyBlockFromJSON(t *testing.T) {
+	type test struct {
+		name    string
+		data    string
+		fullTxs bool
+		txs     int
+		fail    bool
+		// notFound is set when the error must be ErrBlockNotFound.
+		notFound bool
+	}
+
+	full := `{"number":"0x10","transactions":[{"hash":"0x01","type":"0x2"},{"hash":"0x02","type":"0x0"}]}`
+	hashes := `{"number":"0x10","transactions":["0x01","0x02"]}`
+	tests := []test{
+		{name: "full", data: full, fullTxs: true, txs: 2},
+		{name: "hashes only", data: hashes, txs: 2},
+		{name: "hashes only with full transactions", data: hashes, fullTxs: true, fail: true},
+		{name: "no transactions", data: `{"number":"0x10","transactions":[]}`, fullTxs: true},
+		{name: "null", data: "null", fail: true, notFound: true},
+		{name: "empty", data: " ", fail: true, notFound: true},
+		{name: "malformed", data: `{"number":`, fail: true},
+	}
+
+	for _, tc := range tests {
+		block, err := NewPolyBlockFromJSON([]byte(tc.data), tc.fullTxs)
+		if tc.fail {
+			if err == nil {
+				t.Errorf("%s: expected an error", tc.name)
+			} else if errors.Is(err, ErrBlockNotFound) != tc.notFound {
+				t.Errorf("%s: expected not found %t, got %v", tc.name, tc.notFound, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: expected no error, got %v", tc.name, err)
+			continue
+		}
+		if block.Number().Uint64() != 16 || block.TransactionCount() != tc.txs {
+			t.Errorf("%s: expected block 16 with %d transactions, got %s with %d", tc.name, tc.txs, block.Number(), block.TransactionCount())
+		}
+	}
+}
+
+func TestEstimatedStorageBytes(t *testing.T) {
+	hash := RawData32Response("0x" + strings.Repeat("ab", 32))
+	addr := RawData20Response("0x" + strings.Repeat("cd", 20))
+
+	raw := &RawBlockResponse{
+		Number:           "0x2faf080",
+		Hash:             hash,
+		ParentHash:       hash,
+		Nonce:            "0x0000000000000000",
+		SHA3Uncles:       hash,
+		LogsBloom:        RawData256Response("0x" + strings.Repeat("00", 256)),
+		TransactionsRoot: hash,
+		StateRoot:        hash,
+		ReceiptsRoot:     hash,
+		Miner:            addr,
+		Difficulty:       "0x14",
+		TotalDifficulty:  "0x2d7e3a9c",
+		ExtraData:        RawDataResponse("0x" + strings.Repeat("ef", 97)),
+		Size:             "0x1a2b",
+		GasLimit:         "0x1c9c380",
+		GasUsed:          "0x8f0d18",
+		Timestamp:        "0x65a1b2c3",
+		BaseFeePerGas:    "0x7aef40a00",
+		MixHash:          hash,
+	}
+	for idx := 0; idx < 50; idx++ {
+		raw.Transactions = append(raw.Transactions, RawTransactionResponse{
+			BlockHash:            hash,
+			BlockNumber:          "0x2faf080",
+			From:                 addr,
+			Gas:                  "0x186a0",
+			GasPrice:             "0x8f0d18000",
+			MaxPriorityFeePerGas: "0x6fc23ac00",
+			MaxFeePerGas:         "0xba43b7400",
+			Hash:                 hash,
+			Input:                RawDataResponse("0x" + strings.Repeat("12", 4+idx*32)),
+			Nonce:                "0x1f4",
+			To:                   addr,
+			TransactionIndex:     RawQuantityResponse(fmt.Sprintf("0x%x", idx)),
+			Value:                "0xde0b6b3a7640000",
+			V:                    "0x1",
+			R:                    RawQuantityResponse(hash),
+			S:                    RawQuantityResponse(hash),
+			Type:                 "0x2",
+			ChainID:              "0x89",
+		})
+	}
+
+	actual, err := json.Marshal(raw)
+	if err != nil {
+		t.Fatalf("could not encode block: %v", err)
+	}
+	estimate := NewPolyBlock(raw).EstimatedStorageBytes()
+	if diff := math.Abs(float64(estimate-len(actual))) / float64(len(actual)); diff > 0.1 {
+		t.Errorf("estimate %d is more than 10%% off the actual size %d", estimate, len(actual))
+	}
+}
+
+func TestMinerTransactions(t *testing.T) {
+	miner := "0x00000000000000000000000000000000000000aa"
+	block := NewPolyBlock(&RawBlockResponse{
+		Miner: RawData20Response(miner),
+		Transactions: []RawTransactionResponse{
+			{From: RawData20Response(miner), Gas: "0x5208", Value: "0x64"},
+			{From: "0x00000000000000000000000000000000000000bb", Gas: "0x5208", Value: "0x1"},
+			{From: RawData20Response(miner), Gas: "0x7530", Value: "0x0"},
+		},
+	})
+
+	count, gas, value := block.MinerTransactions()
+	if count != 2 || gas != 21000+30000 || value.Int64() != 100 {
+		t.Errorf("expected 2 transactions with 51000 gas and 100 wei, got %d with %d gas and %s wei", count, gas, value)
+	}
+
+	count, gas, value = NewPolyBlock(&RawBlockResponse{Miner: RawData20Response(miner)}).MinerTransactions()
+	if count != 0 || gas != 0 || value.Sign() != 0 {
+		t.Errorf("expected zeros for a block without miner transactions, got %d, %d and %s", count, gas, value)
+	}
+}
+
+func TestGroupBySender(t *testing.T) {
+	txs := PolyTransactions{
+		NewPolyTransaction(&RawTransactionResponse{From: "0x00000000000000000000000000000000000000aa", Nonce: "0x1"}),
+		NewPolyTransaction(&RawTransactionResponse{From: "0x00000000000000000000000000000000000000bb", Nonce: "0x7"}),
+		NewPolyTransaction(&RawTransactionResponse{From: "0x00000000000000000000000000000000000000AA", Nonce: "0x2"}),
+		NewPolyTransaction(&RawTransactionResponse{From: "0x00000000000000000000000000000000000000aa", Nonce: "0x3"}),
+	}
+
+	groups := txs.GroupBySender()
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 senders, got %d", len(groups))
+	}
+
+	aa := groups[ethcommon.HexToAddress("0xaa")]
+	if len(aa) != 3 {
+		t.Fatalf("expected 3 transactions from 0xaa, got %d", len(aa))
+	}
+	for idx, tx := range aa {
+		if tx.Nonce() != uint64(idx+1) {
+			t.Errorf("expected nonce %d at position %d, got %d", idx+1, idx, tx.Nonce())
+		}
+	}
+	if bb := groups[ethcommon.HexToAddress("0xbb")]; len(bb) != 1 {
+		t.Errorf("expected 1 transaction from 0xbb, got %d", len(bb))
+	}
+}
+
+func TestGroupByBlock(t *testing.T) {
+	// Two blocks worth of transactions, shuffled. The hash is the block number
+	// followed by the transaction index, so the order can be checked.
+	txs := PolyTransactions{
+		NewPolyTransaction(&RawTransactionResponse{Hash: "0x0b02", BlockNumber: "0xb", TransactionIndex: "0x2"}),
+		NewPolyTransaction(&RawTransactionResponse{Hash: "0x0a01", BlockNumber: "0xa", TransactionIndex: "0x1"}),
+		NewPolyTransaction(&RawTransactionResponse{Hash: "0x0b00", BlockNumber: "0xb", TransactionIndex: "0x0"}),
+		NewPolyTransaction(&RawTransactionResponse{Hash: "0x0a00", BlockNumber: "0xa", TransactionIndex: "0x0"}),
+		NewPolyTransaction(&RawTransactionResponse{Hash: "0x0b01", BlockNumber: "0xb", TransactionIndex: "0x1"}),
+		// Without an index, block 12 keeps the input order.
+		NewPolyTransaction(&RawTransactionResponse{Hash: "0x0c01", BlockNumber: "0xc", TransactionIndex: "0x1"}),
+		NewPolyTransaction(&RawTransactionResponse{Hash: "0x0c00", BlockNumber: "0xc"}),
+	}
+
+	groups := txs.GroupByBlock()
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 blocks, got %d", len(groups))
+	}
+
+	expected := map[uint64][]string{
+		10: {"0x0a00", "0x0a01"},
+		11: {"0x0b00", "0x0b01", "0x0b02"},
+		12: {"0x0c01", "0x0c00"},
+	}
+	for number, hashes := range expected {
+		group := groups[number]
+		if len(group) != len(hashes) {
+			t.Errorf("expected %d transactions in block %d, got %d", len(hashes), number, len(group))
+			continue
+		}
+		for idx, tx := range group {
+			if tx.Hash() != ethcommon.HexToHash(hashes[idx]) {
+				t.Errorf("expected %s at position %d of block %d, got %s", hashes[idx], idx, number, tx.Hash())
+			}
+		}
+	}
+}
+
+func TestBlobGas(t *testing.T) {
+	var raw RawBlockResponse
+	data := `{"number":"0x1","baseFeePerGas":"0x7","blobGasUsed":"0xc0000","excessBlobGas":"0x1e00000"}`
+	if err := json.Unmarshal([]byte(data), &raw); err != nil {
+		t.Fatalf("unable to decode block: %v", err)
+	}
+
+	block := NewPolyBlock(&raw)
+	if block.BlobGasUsed() != 786432 {
+		t.Errorf("expected 786432 blob gas used, got %d", block.BlobGasUsed())
+	}
+	if block.ExcessBlobGas() != 31457280 {
+		t.Errorf("expected 31457280 excess blob gas, got %d", block.ExcessBlobGas())
+	}
+	// e^(31457280 / 3338477), rounded down by the integer approximation.
+	if fee := block.BlobBaseFee(); fee == nil || fee.Int64() != 12365 {
+		t.Errorf("expected blob base fee 12365, got %v", fee)
+	}
+
+	// Zero excess blob gas is the minimum blob base fee of 1 wei.
+	if fee := NewPolyBlock(&RawBlockResponse{ExcessBlobGas: "0x0"}).BlobBaseFee(); fee == nil || fee.Int64() != 1 {
+		t.Errorf("expected blob base fee 1, got %v", fee)
+	}
+
+	preCancun := NewPolyBlock(&RawBlockResponse{BaseFeePerGas: "0x7"})
+	if preCancun.BlobGasUsed() != 0 || preCancun.ExcessBlobGas() != 0 || preCancun.BlobBaseFee() != nil {
+		t.Errorf("expected no blob gas before Cancun, got %d used, %d excess and fee %v",
+			preCancun.BlobGasUsed(), preCancun.ExcessBlobGas(), preCancun.BlobBaseFee())
+	}
+}
+
+func TestBlobTransaction(t *testing.T) {
+	var raw RawTransactionResponse
+	data := `{"type": "0x3", "maxFeePerBlobGas": "0x3b9aca00", "blobVersionedHashes": [
+		"0x0100000000000000000000000000000000000000000000000000000000000001",
+		"0x0100000000000000000000000000000000000000000000000000000000000002"]}`
+	if err := json.Unmarshal([]byte(data), &raw); err != nil {
+		t.Fatalf("could not decode transaction: %v", err)
+	}
+
+	tx := NewPolyTransaction(&raw)
+	if fee := tx.MaxFeePerBlobGas(); fee == nil || fee.Int64() != 1000000000 {
+		t.Errorf("expected max fee per blob gas 1000000000, got %v", fee)
+	}
+	hashes := tx.BlobVersionedHashes()
+	if len(hashes) != 2 || hashes[1] != ethcommon.HexToHash("0x0100000000000000000000000000000000000000000000000000000000000002") {
+		t.Errorf("unexpected blob versioned hashes %v", hashes)
+	}
+
+	legacy := NewPolyTransaction(&RawTransactionResponse{GasPrice: "0x1e"})
+	if fee := legacy.MaxFeePerBlobGas(); fee != nil {
+		t.Errorf("expected no max fee per blob gas, got %s", fee)
+	}
+	if hashes := legacy.BlobVersionedHashes(); hashes == nil || len(hashes) != 0 {
+		t.Errorf("expected no blob versioned hashes, got %v", hashes)
+	}
+}
+
+func TestWithdrawals(t *testing.T) {
+	var raw RawBlockResponse
+	data := `{"withdrawalsRoot": "0x00000000000000000000000000000000000000000000000000000000000000aa", "withdrawals": [
+		{"index": "0x10", "validatorIndex": "0x2a", "address": "0x00000000000000000000000000000000000000bb", "amount": "0x3b9aca00"}]}`
+	if err := json.Unmarshal([]byte(data), &raw); err != nil {
+		t.Fatalf("could not decode block: %v", err)
+	}
+
+	block := NewPolyBlock(&raw)
+	if root := block.WithdrawalsRoot(); root != ethcommon.HexToHash("0xaa") {
+		t.Errorf("expected withdrawals root 0xaa, got %s", root)
+	}
+	withdrawals := block.Withdrawals()
+	if len(withdrawals) != 1 {
+		t.Fatalf("expected 1 withdrawal, got %d", len(withdrawals))
+	}
+	w := withdrawals[0]
+	if w.Index != 16 || w.Validator != 42 || w.Address != ethcommon.HexToAddress("0xbb") || w.Amount != 1000000000 {
+		t.Errorf("unexpected withdrawal %+v", w)
+	}
+
+	old := NewPolyBlock(&RawBlockResponse{})
+	if old.Withdrawals() != nil || old.WithdrawalsRoot() != (ethcommon.Hash{}) {
+		t.Errorf("expected no withdrawals and a zero root before Shanghai")
+	}
+}
+
+func TestPolyBlocks(t *testing.T) {
+	blocks := PolyBlocks{
+		NewPolyBlock(&RawBlockResponse{
+			GasUsed:      "0x3e8", // 1000
+			GasLimit:     "0x7d0", // 2000
+			Timestamp:    "0x6e",  // 110
+			Transactions: []RawTransactionResponse{{}, {}},
+		}),
+		NewPolyBlock(&RawBlockResponse{
+			GasUsed:           "0x7d0", // 2000
+			GasLimit:          "0xfa0", // 4000
+			Timestamp:         "0x64",  // 100
+			TransactionHashes: []RawData32Response{"0x01", "0x02", "0x03"},
+		}),
+		NewPolyBlock(&RawBlockResponse{
+			GasUsed:   "0x0",
+			GasLimit:  "0xfa0", // 4000
+			Timestamp: "0x78",  // 120
+		}),
+	}
+
+	if gas := blocks.TotalGasUsed(); gas != 3000 {
+		t.Errorf("expected 3000 gas used, got %d", gas)
+	}
+	if txs := blocks.TotalTransactions(); txs != 5 {
+		t.Errorf("expected 5 transactions, got %d", txs)
+	}
+	if utilization := blocks.AverageGasUtilization(); utilization != 0.3 {
+		t.Errorf("expected 0.3 gas utilization, got %v", utilization)
+	}
+	if span := blocks.TimeSpan(); span != 20 {
+		t.Errorf("expected a time span of 20 seconds, got %d", span)
+	}
+
+	var empty PolyBlocks
+	if empty.TotalGasUsed() != 0 || empty.TotalTransactions() != 0 || empty.AverageGasUtilization() != 0 || empty.TimeSpan() != 0 {
+		t.Errorf("expected zeros for no blocks")
+	}
+}
+
+func TestGasTarget(t *testing.T) {
+	type test struct {
+		name      string
+		gasUsed   RawQuantityResponse
+		direction int
+	}
+
+	tests := []test{
+		{name: "below target", gasUsed: "0x5208", direction: -1},
+		{name: "at target", gasUsed: "0xe4e1c0", direction: 0},
+		{name: "above target", gasUsed: "0x1c9c380", direction: 1},
+	}
+
+	for _, test := range tests {
+		block := NewPolyBlock(&RawBlockResponse{GasLimit: "0x1c9c380", GasUsed: test.gasUsed, BaseFeePerGas: "0x7"})
+		target := block.GasTarget(params.DefaultElasticityMultiplier)
+		if target != 15000000 {
+			t.Errorf("%s: expected gas target 15000000, got %d", test.name, target)
+		}
+		direction := 0
+		if block.GasUsed() > target {
+			direction = 1
+		} else if block.GasUsed() < target {
+			direction = -1
+		}
+		if direction != test.direction {
+			t.Errorf("%s: expected direction %d, got %d", test.name, test.direction, direction)
+		}
+	}
+
+	if target := NewPolyBlock(&RawBlockResponse{GasLimit: "0x1c9c380"}).GasTarget(params.DefaultElasticityMultiplier); target != 0 {
+		t.Errorf("expected gas target 0 before London, got %d", target)
+	}
+
+	london := NewPolyBlock(&RawBlockResponse{GasLimit: "0x1c9c380", BaseFeePerGas: "0x7"})
+	if target := london.GasTarget(4); target != 7500000 {
+		t.Errorf("expected gas target 7500000 with an elasticity of 4, got %d", target)
+	}
+	if target := london.GasTarget(0); target != 0 {
+		t.Errorf("expected gas target 0 without an elasticity, got %d", target)
+	}
+}
+
+func TestGasUtilization(t *testing.T) {
+	type test struct {
+		name        string
+		block       RawBlockResponse
+		utilization float64
+		empty       bool
+	}
+
+	tests := []test{
+		{name: "half full", block: RawBlockResponse{GasLimit: "0x1c9c380", GasUsed: "0xe4e1c0", Transactions: []RawTransactionResponse{{Hash: "0x01"}}}, utilization: 0.5},
+		{name: "full", block: RawBlockResponse{GasLimit: "0x1c9c380", GasUsed: "0x1c9c380", TransactionHashes: []RawData32Response{"0x01"}}, utilization: 1},
+		{name: "empty", block: RawBlockResponse{GasLimit: "0x1c9c380"}, utilization: 0, empty: true},
+		{name: "no gas limit", block: RawBlockResponse{GasUsed: "0x5208"}, utilization: 0, empty: true},
+	}
+
+	for _, tc := range tests {
+		block := NewPolyBlock(&tc.block)
+		if utilization := block.GasUtilization(); utilization != tc.utilization {
+			t.Errorf("%s: expected utilization %v, got %v", tc.name, tc.utilization, utilization)
+		}
+		if block.IsEmpty() != tc.empty {
+			t.Errorf("%s: expected empty to be %v", tc.name, tc.empty)
+		}
+	}
+}
+
+func TestDedupTransactions(t *testing.T) {
+	blocks := []PolyBlock{
+		NewPolyBlock(&RawBlockResponse{Transactions: []RawTransactionResponse{{Hash: "0x01"}, {Hash: "0x02"}, {Hash: "0x01"}}}),
+		NewPolyBlock(&RawBlockResponse{Transactions: []RawTransactionResponse{{Hash: "0x03"}, {Hash: "0x02"}}}),
+	}
+
+	txs, removed := DedupTransactions(blocks)
+	if removed != 2 {
+		t.Errorf("expected 2 duplicates removed, got %d", removed)
+	}
+	expected := []ethcommon.Hash{ethcommon.HexToHash("0x01"), ethcommon.HexToHash("0x02"), ethcommon.HexToHash("0x03")}
+	if len(txs) != len(expected) {
+		t.Fatalf("expected %d transactions, got %d", len(expected), len(txs))
+	}
+	for idx, hash := range expected {
+		if txs[idx].Hash() != hash {
+			t.Errorf("expected transaction %s at position %d, got %s", hash, idx, txs[idx].Hash())
+		}
+	}
+}
+
+func TestConfirmations(t *testing.T) {
+	type test struct {
+		name          string
+		blockNumber   RawQuantityResponse
+		head          int64
+		confirmations uint64
+	}
+
+	tests := []test{
+		{name: "pending", blockNumber: "", head: 100, confirmations: 0},
+		{name: "head block", blockNumber: "0x64", head: 100, confirmations: 1},
+		{name: "confirmed", blockNumber: "0x5a", head: 100, confirmations: 11},
+		{name: "head below block", blockNumber: "0x64", head: 90, confirmations: 0},
+	}
+
+	for _, test := range tests {
+		tx := NewPolyTransaction(&RawTransactionResponse{BlockNumber: test.blockNumber})
+		if c := tx.Confirmations(big.NewInt(test.head)); c != test.confirmations {
+			t.Errorf("%s: expected %d confirmations, got %d", test.name, test.confirmations, c)
+		}
+	}
+}
+
+func TestEstimateEffectiveGasPrice(t *testing.T) {
+	dynamic := NewPolyTransaction(&RawTransactionResponse{GasPrice: "0x1e", MaxFeePerGas: "0x32", MaxPriorityFeePerGas: "0x5"})
+	legacy := NewPolyTransaction(&RawTransactionResponse{GasPrice: "0x1e"})
+
+	type test struct {
+		name     string
+		tx       PolyTransaction
+		baseFee  *big.Int
+		receipt  PolyReceipt
+		expected int64
+	}
+
+	tests := []test{
+		{name: "receipt", tx: dynamic, baseFee: big.NewInt(20), receipt: NewPolyReceipt(&RawTxReceipt{EffectiveGasPrice: "0x17"}), expected: 23},
+		{name: "receipt without price", tx: dynamic, baseFee: big.NewInt(20), receipt: NewPolyReceipt(&RawTxReceipt{}), expected: 25},
+		{name: "dynamic fee", tx: dynamic, baseFee: big.NewInt(20), expected: 25},
+		{name: "dynamic fee capped", tx: dynamic, baseFee: big.NewInt(48), expected: 50},
+		{name: "dynamic fee without base fee", tx: dynamic, baseFee: nil, expected: 30},
+		{name: "legacy", tx: legacy, baseFee: big.NewInt(20), expected: 30},
+	}
+
+	for _, test := range tests {
+		if price := EstimateEffectiveGasPrice(test.tx, test.baseFee, test.receipt); price.Int64() != test.expected {
+			t.Errorf("%s: expected %d, got %s", test.name, test.expected, price)
+		}
+	}
+}
+
+func TestFeeCaps(t *testing.T) {
+	dynamic := NewPolyTransaction(&RawTransactionResponse{Type: "0x2", GasPrice: "0x1e", MaxFeePerGas: "0x32", MaxPriorityFeePerGas: "0x5"})
+	if fee := dynamic.MaxFeePerGas(); fee.Cmp(big.NewInt(50)) != 0 {
+		t.Errorf("expected max fee per gas 50, got %s", fee)
+	}
+	if tip := dynamic.MaxPriorityFeePerGas(); tip.Cmp(big.NewInt(5)) != 0 {
+		t.Errorf("expected max priority fee per gas 5, got %s", tip)
+	}
+
+	// Legacy transactions fall back to their gas price.
+	var legacy RawTransactionResponse
+	if err := json.Unmarshal([]byte(`{"type": "0x0", "gasPrice": "0x1e"}`), &legacy); err != nil {
+		t.Fatalf("could not decode transaction: %v", err)
+	}
+	tx := NewPolyTransaction(&legacy)
+	if fee := tx.MaxFeePerGas(); fee.Cmp(big.NewInt(30)) != 0 {
+		t.Errorf("expected legacy max fee per gas 30, got %s", fee)
+	}
+	if tip := tx.MaxPriorityFeePerGas(); tip.Cmp(big.NewInt(30)) != 0 {
+		t.Errorf("expected legacy max priority fee per gas 30, got %s", tip)
+	}
+}
+
+func TestChainID(t *testing.T) {
+	if id := NewPolyTransaction(&RawTransactionResponse{ChainID: "0x89"}).ChainID(); id == nil || id.Int64() != 137 {
+		t.Errorf("expected chain id 137, got %v", id)
+	}
+	if id := NewPolyTransaction(&RawTransactionResponse{ChainID: "0x0"}).ChainID(); id == nil || id.Sign() != 0 {
+		t.Errorf("expected chain id 0, got %v", id)
+	}
+
+	var legacy RawTransactionResponse
+	if err := json.Unmarshal([]byte(`{"type": "0x0", "gasPrice": "0x1e"}`), &legacy); err != nil {
+		t.Fatalf("could not decode transaction: %v", err)
+	}
+	if id := NewPolyTransaction(&legacy).ChainID(); id != nil {
+		t.Errorf("expected no chain id for a pre-EIP-155 transaction, got %s", id)
+	}
+}
+
+func TestAccessList(t *testing.T) {
+	var raw RawTransactionResponse
+	data := `{"type": "0x1", "accessList": [{"address": "0x00000000000000000000000000000000000000aa", "storageKeys": [
+		"0x0000000000000000000000000000000000000000000000000000000000000001",
+		"0x0000000000000000000000000000000000000000000000000000000000000002"]}]}`
+	if err := json.Unmarshal([]byte(data), &raw); err != nil {
+		t.Fatalf("could not decode transaction: %v", err)
+	}
+
+	accessList := NewPolyTransaction(&raw).AccessList()
+	if len(accessList) != 1 {
+		t.Fatalf("expected 1 access tuple, got %d", len(accessList))
+	}
+	if accessList[0].Address != ethcommon.HexToAddress("0xaa") {
+		t.Errorf("expected address 0xaa, got %s", accessList[0].Address)
+	}
+	if keys := accessList[0].StorageKeys; len(keys) != 2 || keys[0] != ethcommon.HexToHash("0x01") || keys[1] != ethcommon.HexToHash("0x02") {
+		t.Errorf("expected storage keys 0x01 and 0x02, got %v", keys)
+	}
+
+	if legacy := NewPolyTransaction(&RawTransactionResponse{}).AccessList(); legacy == nil || len(legacy) != 0 {
+		t.Errorf("expected an empty access list, got %v", legacy)
+	}
+}
+
+func TestChecksumAddresses(t *testing.T) {
+	from := RawData20Response("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed")
+	if checksummed := from.ToChecksumString(); checksummed != "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed" {
+		t.Errorf("expected the checksummed address, got %s", checksummed)
+	}
+	creation := RawData20Response("")
+	if to := creation.ToChecksumString(); to != "" {
+		t.Errorf("expected an empty address to stay empty, got %s", to)
+	}
+
+	tx := NewPolyTransaction(&RawTransactionResponse{From: from})
+	if !strings.Contains(tx.String(), string(from)) {
+		t.Errorf("expected the node's casing by default, got %s", tx.String())
+	}
+
+	if s := tx.ChecksumString(); !strings.Contains(s, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed") || !strings.Contains(s, `"to":""`) {
+		t.Errorf("expected the checksummed address, got %s", s)
+	}
+	if tx.From() != from.ToAddress() {
+		t.Errorf("expected the address to be unchanged")
+	}
+}
+
+func TestMaxDataBytes(t *testing.T) {
+	defer func(n int) { MaxDataBytes = n }(MaxDataBytes)
+	MaxDataBytes = 4
+
+	data := RawDataResponse("0x01020304")
+	if b, err := data.Decode(); err != nil || len(b) != 4 {
+		t.Errorf("expected data at the limit to decode, got %x and %v", b, err)
+	}
+
+	oversized := RawDataResponse("0x" + strings.Repeat("ff", 5))
+	if _, err := oversized.Decode(); !errors.Is(err, ErrDataTooLarge) {
+		t.Errorf("expected an oversized data error, got %v", err)
+	}
+	if b := oversized.ToBytes(); b != nil {
+		t.Errorf("expected no bytes for oversized data, got %x", b)
+	}
+	tx := NewPolyTransaction(&RawTransactionResponse{Input: oversized})
+	if len(tx.Data()) != 0 {
+		t.Errorf("expected no transaction data for oversized input, got %x", tx.Data())
+	}
+}
+
+func TestMethodSelector(t *testing.T) {
+	type test struct {
+		name     string
+		tx       RawTransactionResponse
+		selector string
+		ok       bool
+	}
+
+	to := RawData20Response("0x00000000000000000000000000000000000000aa")
+	tests := []test{
+		{name: "call", tx: RawTransactionResponse{To: to, Input: "0xa9059cbb0000"}, selector: "0xa9059cbb", ok: true},
+		{name: "transfer", tx: RawTransactionResponse{To: to, Input: "0x"}, selector: "", ok: false},
+		{name: "short input", tx: RawTransactionResponse{To: to, Input: "0xa9059c"}, selector: "", ok: false},
+		{name: "creation", tx: RawTransactionResponse{Input: "0x6080604052"}, selector: "", ok: false},
+	}
+
+	for _, test := range tests {
+		tx := NewPolyTransaction(&test.tx)
+		selector, ok := tx.MethodSelector()
+		if ok != test.ok {
+			t.Errorf("%s: expected has selector %v, got %v", test.name, test.ok, ok)
+		}
+		if !ok && selector != [4]byte{} {
+			t.Errorf("%s: expected a zero selector, got %x", test.name, selector)
+		}
+		if hex := tx.MethodSelectorHex(); hex != test.selector {
+			t.Errorf("%s: expected selector %q, got %q", test.name, test.selector, hex)
+		}
+	}
+}
+
+func TestParseHexString(t *testing.T) {
+	type test struct {
+		name     string
+		input    string
+		expected string
+		err      bool
+	}
+
+	tests := []test{
+		{name: "plain", input: "0x1a", expected: "1a"},
+		{name: "whitespace", input: " \t0x1a\n", expected: "1a"},
+		{name: "mixed case", input: "0XaBcD", expected: "abcd"},
+		{name: "odd length", input: "0xabc", expected: "0abc"},
+		{name: "no prefix", input: "ff", expected: "ff"},
+		{name: "empty", input: "", expected: ""},
+		{name: "embedded prefix", input: "0x120x34", err: true},
+		{name: "double prefix", input: "0x0x12", err: true},
+		{name: "inner whitespace", input: "0x12 34", err: true},
+		{name: "non hex", input: "0x12zz", err: true},
+	}
+
+	for _, tc := range tests {
+		hexString, err := ParseHexString(tc.input)
+		if (err != nil) != tc.err {
+			t.Errorf("%s: expected error %v, got %v", tc.name, tc.err, err)
+			continue
+		}
+		if hexString != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.expected, hexString)
+		}
+	}
+
+	// The Raw* conversions go through the same parsing.
+	quantity := RawQuantityResponse(" 0X1A ")
+	if quantity.ToUint64() != 26 || quantity.ToBigInt().Int64() != 26 {
+		t.Errorf("expected the padded quantity to decode to 26, got %d", quantity.ToUint64())
+	}
+	if malformed := RawQuantityResponse("0x0x1a"); malformed.ToUint64() != 0 {
+		t.Errorf("expected a malformed quantity to decode to 0, got %d", malformed.ToUint64())
+	}
+	address := RawData20Response(" 0x00000000000000000000000000000000000000AA ")
+	if address.ToAddress() != ethcommon.HexToAddress("0xaa") {
+		t.Errorf("expected address 0xaa, got %s", address.ToAddress())
+	}
+	data := RawDataResponse("0x12 0x34")
+	if _, err := data.Decode(); err == nil {
+		t.Errorf("expected an error decoding data with an embedded prefix")
+	}
+}
+
+func TestRawBytes(t *testing.T) {
+	key, err := ethcrypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("could not generate key: %v", err)
+	}
+	chainID := big.NewInt(137)
+	signer := ethtypes.LatestSignerForChainID(chainID)
+	to := ethcommon.HexToAddress("0xbb")
+
+	txs := []ethtypes.TxData{
+		&ethtypes.LegacyTx{Nonce: 1, GasPrice: big.NewInt(30), Gas: 21000, To: &to, Value: big.NewInt(1)},
+		&ethtypes.AccessListTx{ChainID: chainID, Nonce: 2, GasPrice: big.NewInt(30), Gas: 30000, To: &to, Data: []byte{0xa9, 0x05, 0x9c, 0xbb},
+			AccessList: ethtypes.AccessList{{Address: to, StorageKeys: []ethcommon.Hash{{0x01}}}}},
+		&ethtypes.DynamicFeeTx{ChainID: chainID, Nonce: 3, GasTipCap: big.NewInt(2), GasFeeCap: big.NewInt(40), Gas: 50000, Data: []byte{0x60, 0x80}},
+		&ethtypes.BlobTx{ChainID: uint256.NewInt(137), Nonce: 4, GasTipCap: uint256.NewInt(2), GasFeeCap: uint256.NewInt(40), Gas: 21000, To: to,
+			Value: uint256.NewInt(0), BlobFeeCap: uint256.NewInt(3), BlobHashes: []ethcommon.Hash{{0x01, 0xaa}}},
+	}
+
+	for _, data := range txs {
+		signed, err := ethtypes.SignNewTx(key, signer, data)
+		if err != nil {
+			t.Fatalf("could not sign transaction: %v", err)
+		}
+		encoded, err := signed.MarshalJSON()
+		if err != nil {
+			t.Fatalf("could not encode transaction: %v", err)
+		}
+		var raw RawTransactionResponse
+		if err = json.Unmarshal(encoded, &raw); err != nil {
+			t.Fatalf("could not decode transaction: %v", err)
+		}
+
+		rawBytes, err := NewPolyTransaction(&raw).RawBytes()
+		if err != nil {
+			t.Errorf("type %d: could not get raw bytes: %v", signed.Type(), err)
+			continue
+		}
+
+		decoded := new(ethtypes.Transaction)
+		if err = decoded.UnmarshalBinary(rawBytes); err != nil {
+			t.Errorf("type %d: could not decode raw bytes: %v", signed.Type(), err)
+			continue
+		}
+		if decoded.Type() != signed.Type() || decoded.Hash() != signed.Hash() {
+			t.Errorf("type %d: expected hash %s, got type %d with hash %s", signed.Type(), signed.Hash(), decoded.Type(), decoded.Hash())
+		}
+	}
+
+	// State sync transactions aren't signed, so they can't be encoded.
+	if _, err = NewPolyTransaction(&RawTransactionResponse{Type: "0x0", Gas: "0x0"}).RawBytes(); err == nil {
+		t.Errorf("expected an error encoding an unsigned transaction")
+	}
+}
+
+func TestRecoverSender(t *testing.T) {
+	key, err := ethcrypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("could not generate key: %v", err)
+	}
+	from := ethcrypto.PubkeyToAddress(key.PublicKey)
+	chainID := big.NewInt(137)
+	to := ethcommon.HexToAddress("0xbb")
+
+	type test struct {
+		name    string
+		signer  ethtypes.Signer
+		data    ethtypes.TxData
+		chainID *big.Int
+	}
+
+	tests := []test{
+		{name: "unprotected legacy", signer: ethtypes.HomesteadSigner{}, data: &ethtypes.LegacyTx{Nonce: 1, GasPrice: big.NewInt(30), Gas: 21000, To: &to}},
+		{name: "legacy", signer: ethtypes.LatestSignerForChainID(chainID), chainID: chainID,
+			data: &ethtypes.LegacyTx{Nonce: 1, GasPrice: big.NewInt(30), Gas: 21000, To: &to}},
+		{name: "dynamic fee", signer: ethtypes.LatestSignerForChainID(chainID),
+			data: &ethtypes.DynamicFeeTx{ChainID: chainID, Nonce: 2, GasTipCap: big.NewInt(2), GasFeeCap: big.NewInt(40), Gas: 21000, To: &to}},
+	}
+
+	for _, tc := range tests {
+		signed, err := ethtypes.SignNewTx(key, tc.signer, tc.data)
+		if err != nil {
+			t.Fatalf("%s: could not sign transaction: %v", tc.name, err)
+		}
+		encoded, err := signed.MarshalJSON()
+		if err != nil {
+			t.Fatalf("%s: could not encode transaction: %v", tc.name, err)
+		}
+		var raw RawTransactionResponse
+		if err = json.Unmarshal(encoded, &raw); err != nil {
+			t.Fatalf("%s: could not decode transaction: %v", tc.name, err)
+		}
+		raw.From = RawData20Response(from.Hex())
+
+		sender, err := NewPolyTransaction(&raw).RecoverSender(tc.chainID, true)
+		if err != nil || sender != from {
+			t.Errorf("%s: expected sender %s, got %s (%v)", tc.name, from, sender, err)
+		}
+
+		// A node reporting a different sender is caught when verifying.
+		raw.From = "0x00000000000000000000000000000000000000aa"
+		if _, err = NewPolyTransaction(&raw).RecoverSender(tc.chainID, true); err == nil {
+			t.Errorf("%s: expected an error for a mismatched sender", tc.name)
+		}
+		if sender, err = NewPolyTransaction(&raw).RecoverSender(tc.chainID, false); err != nil || sender != from {
+			t.Errorf("%s: expected sender %s without verifying, got %s (%v)", tc.name, from, sender, err)
+		}
+	}
+}
+
+func TestCachedQuantities(t *testing.T) {
+	block := NewPolyBlock(&RawBlockResponse{Number: "0x10", BaseFeePerGas: "0x7"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Modifying the result doesn't change the cached value.
+			block.Number().Add(block.Number(), big.NewInt(1))
+			block.BaseFee().SetUint64(0)
+		}()
+	}
+	wg.Wait()
+
+	if block.Number().Uint64() != 16 || block.BaseFee().Uint64() != 7 {
+		t.Errorf("expected number 16 and base fee 7, got %s and %s", block.Number(), block.BaseFee())
+	}
+}
+
+// BenchmarkBlockQuantities reads the block's number and base fee once per
+// transaction of a 500 transaction block, as per transaction loops do, with
+// the cached accessors and by decoding the raw quantities each time.
+func BenchmarkBlockQuantities(b *testing.B) {
+	raw := &RawBlockResponse{
+		Number:        "0x3a6c5f1",
+		Difficulty:    "0x19",
+		BaseFeePerGas: "0x6fc23ac00",
+		Transactions:  make([]RawTransactionResponse, 500),
+	}
+	block := NewPolyBlock(raw)
+
+	b.Run("cached", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			for range raw.Transactions {
+				_ = block.Number()
+				_ = block.BaseFee()
+			}
+		}
+	})
+	b.Run("decoded", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			for range raw.Transactions {
+				_ = raw.Number.ToBigInt()
+				_ = raw.BaseFeePerGas.ToBigInt()
+			}
+		}
+	})
+}
+
+func TestLogsBloomContains(t *testing.T) {
+	// Set the three bits of each value by hand, following the yellow paper,
+	// rather than with go-ethereum's bloom.
+	var bloom [256]byte
+	add := func(data []byte) {
+		hash := ethcrypto.Keccak256(data)
+		for i := 0; i < 6; i += 2 {
+			bit := (uint(hash[i])<<8 | uint(hash[i+1])) & 2047
+			bloom[256-1-bit/8] |= 1 << (bit % 8)
+		}
+	}
+
+	var addresses []ethcommon.Address
+	var topics []ethcommon.Hash
+	for i := 0; i < 20; i++ {
+		address := ethcommon.BigToAddress(big.NewInt(int64(i + 1)))
+		topic := ethcrypto.Keccak256Hash([]byte(fmt.Sprintf("Event%d(uint256)", i)))
+		add(address.Bytes())
+		add(topic.Bytes())
+		addresses = append(addresses, address)
+		topics = append(topics, topic)
+	}
+	raw := RawData256Response(fmt.Sprintf("%#x", bloom))
+
+	// There must never be false negatives.
+	for _, address := range addresses {
+		if !raw.ContainsAddress(address) {
+			t.Errorf("expected the bloom to contain address %s", address)
+		}
+	}
+	for _, topic := range topics {
+		if !raw.ContainsTopic(topic) {
+			t.Errorf("expected the bloom to contain topic %s", topic)
+		}
+	}
+
+	// False positives are possible, but most values that weren't added should
+	// be missing from a sparse bloom.
+	positives := 0
+	for i := 0; i < 1000; i++ {
+		if raw.ContainsAddress(ethcommon.BigToAddress(big.NewInt(int64(i + 1000)))) {
+			positives++
+		}
+	}
+	if positives > 50 {
+		t.Errorf("expected few false positives, got %d of 1000", positives)
+	}
+
+	empty := RawData256Response(fmt.Sprintf("%#x", [256]byte{}))
+	if empty.ContainsAddress(addresses[0]) {
+		t.Errorf("expected an empty bloom to contain nothing")
+	}
+
+	// Missing and malformed blooms can't rule anything out.
+	for _, raw := range []RawData256Response{"", "0x1234"} {
+		if !raw.ContainsTopic(topics[0]) {
+			t.Errorf("%q: expected the bloom to possibly contain anything", raw)
+		}
+	}
+}
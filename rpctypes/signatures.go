@@ -0,0 +1,135 @@
+package rpctypes
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// SignatureResolver resolves 4 byte method selectors to function signatures,
+// such as transfer(address,uint256), using a local copy of a signature
+// database like 4byte.directory. The database is kept in memory.
+type SignatureResolver struct {
+	signatures map[[4]byte][]string
+}
+
+// NewSignatureResolver creates a resolver from a map of 0x prefixed selectors
+// to their signatures. Several signatures can share a selector, since it's
+// only the first four bytes of the signature's hash.
+func NewSignatureResolver(signatures map[string][]string) (*SignatureResolver, error) {
+	r := &SignatureResolver{signatures: make(map[[4]byte][]string, len(signatures))}
+	for s, sigs := range signatures {
+		data, err := hexutil.Decode(s)
+		if err != nil || len(data) != 4 {
+			return nil, fmt.Errorf("invalid selector %q", s)
+		}
+
+		var selector [4]byte
+		copy(selector[:], data)
+		r.signatures[selector] = append(r.signatures[selector], sigs...)
+	}
+	for _, sigs := range r.signatures {
+		sort.Strings(sigs)
+	}
+	return r, nil
+}
+
+// LoadSignatureResolver reads the signature database from a JSON file that
+// maps selectors to either a signature or a list of colliding signatures, e.g.
+// {"0xa9059cbb": ["transfer(address,uint256)"]}.
+func LoadSignatureResolver(file string) (*SignatureResolver, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]json.RawMessage
+	if err = json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("unable to decode signature database %s: %w", file, err)
+	}
+
+	signatures := make(map[string][]string, len(raw))
+	for selector, value := range raw {
+		var sigs []string
+		if isJSONString(value) {
+			var sig string
+			err = json.Unmarshal(value, &sig)
+			sigs = []string{sig}
+		} else {
+			err = json.Unmarshal(value, &sigs)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid signatures for selector %s: %w", selector, err)
+		}
+		signatures[selector] = sigs
+	}
+	return NewSignatureResolver(signatures)
+}
+
+// Resolve returns the signatures known for the selector, sorted, or nil if
+// there aren't any.
+func (r *SignatureResolver) Resolve(selector [4]byte) []string {
+	return r.signatures[selector]
+}
+
+// ResolveTransaction returns the signatures of the function the transaction
+// calls. If the selector isn't in the database, the raw hex selector is
+// returned instead. Transactions without a selector, such as value transfers
+// and contract creations, return nil.
+func (r *SignatureResolver) ResolveTransaction(tx PolyTransaction) []string {
+	selector, ok := tx.MethodSelector()
+	if !ok {
+		return nil
+	}
+	if sigs := r.Resolve(selector); len(sigs) > 0 {
+		return sigs
+	}
+	return []string{tx.MethodSelectorHex()}
+}
+
+// AnnotateBlock adds a methodSignatures field with the resolved signatures to
+// each of the block's transactions that calls a function, if the block has
+// full transaction objects.
+func (r *SignatureResolver) AnnotateBlock(raw json.RawMessage) (json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, fmt.Errorf("unable to decode block to annotate: %w", err)
+	}
+
+	var txs []json.RawMessage
+	if err := json.Unmarshal(fields["transactions"], &txs); err != nil || len(txs) == 0 || isJSONString(txs[0]) {
+		return raw, nil
+	}
+
+	for idx := range txs {
+		var tx RawTransactionResponse
+		if err := json.Unmarshal(txs[idx], &tx); err != nil {
+			return nil, fmt.Errorf("unable to decode transaction to annotate: %w", err)
+		}
+		sigs := r.ResolveTransaction(NewPolyTransaction(&tx))
+		if sigs == nil {
+			continue
+		}
+
+		var txFields map[string]json.RawMessage
+		if err := json.Unmarshal(txs[idx], &txFields); err != nil {
+			return nil, fmt.Errorf("unable to decode transaction to annotate: %w", err)
+		}
+		var err error
+		if txFields["methodSignatures"], err = json.Marshal(sigs); err != nil {
+			return nil, err
+		}
+		if txs[idx], err = json.Marshal(txFields); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	if fields["transactions"], err = json.Marshal(txs); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
@@ -0,0 +1,78 @@
+package rpctypes
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSignatureResolver(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "signatures.json")
+	db := `{
+		"0xa9059cbb": "transfer(address,uint256)",
+		"0x12345678": ["foo(uint256)", "bar(bytes32)"]
+	}`
+	if err := os.WriteFile(file, []byte(db), 0644); err != nil {
+		t.Fatalf("could not write signature database: %v", err)
+	}
+
+	r, err := LoadSignatureResolver(file)
+	if err != nil {
+		t.Fatalf("could not load signature database: %v", err)
+	}
+
+	to := RawData20Response("0x00000000000000000000000000000000000000bb")
+
+	type test struct {
+		name     string
+		input    RawDataResponse
+		expected []string
+	}
+
+	tests := []test{
+		{name: "known selector", input: "0xa9059cbb0000", expected: []string{"transfer(address,uint256)"}},
+		{name: "collision", input: "0x12345678", expected: []string{"bar(bytes32)", "foo(uint256)"}},
+		{name: "unknown selector", input: "0xdeadbeef", expected: []string{"0xdeadbeef"}},
+		{name: "value transfer", input: "0x", expected: nil},
+	}
+
+	for _, tc := range tests {
+		sigs := r.ResolveTransaction(NewPolyTransaction(&RawTransactionResponse{To: to, Input: tc.input}))
+		if !reflect.DeepEqual(sigs, tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, sigs)
+		}
+	}
+
+	block := []byte(`{"number": "0x1", "transactions": [
+		{"hash": "0x02", "to": "` + string(to) + `", "input": "0xa9059cbb"},
+		{"hash": "0x03", "to": "` + string(to) + `", "input": "0x"}
+	]}`)
+	out, err := r.AnnotateBlock(block)
+	if err != nil {
+		t.Fatalf("could not annotate block: %v", err)
+	}
+
+	var annotated struct {
+		Transactions []struct {
+			MethodSignatures []string `json:"methodSignatures"`
+		} `json:"transactions"`
+	}
+	if err = json.Unmarshal(out, &annotated); err != nil {
+		t.Fatalf("could not decode annotated block: %v", err)
+	}
+	if len(annotated.Transactions) != 2 {
+		t.Fatalf("expected 2 transactions, got %d", len(annotated.Transactions))
+	}
+	if sigs := annotated.Transactions[0].MethodSignatures; !reflect.DeepEqual(sigs, []string{"transfer(address,uint256)"}) {
+		t.Errorf("expected the transfer signature, got %v", sigs)
+	}
+	if sigs := annotated.Transactions[1].MethodSignatures; sigs != nil {
+		t.Errorf("expected no signatures for a value transfer, got %v", sigs)
+	}
+
+	if _, err = NewSignatureResolver(map[string][]string{"0x1234": {"foo()"}}); err == nil {
+		t.Errorf("expected an error for a short selector")
+	}
+}
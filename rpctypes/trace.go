@@ -0,0 +1,150 @@
+package rpctypes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// panicSelector is the selector of Panic(uint256), which solidity reverts with
+// on assertion failures, arithmetic errors and the like.
+var panicSelector = []byte{0x4e, 0x48, 0x7b, 0x71}
+
+// errorSelector is the selector of Error(string), which solidity reverts with
+// for require and revert with a message.
+var errorSelector = []byte{0x08, 0xc3, 0x79, 0xa0}
+
+// CallFrame is a call frame from the callTracer of debug_traceTransaction. The
+// top level frame is the transaction itself and Calls holds the internal
+// calls it made, nested in the order they were made. The structLogger format
+// isn't supported.
+type CallFrame struct {
+	// type: the kind of call, e.g. CALL, STATICCALL, DELEGATECALL, CREATE, CREATE2 or SELFDESTRUCT.
+	Type string `json:"type"`
+
+	// from: DATA, 20 Bytes - address of the caller.
+	From RawData20Response `json:"from"`
+
+	// to: DATA, 20 Bytes - address of the callee, or the created contract.
+	To RawData20Response `json:"to"`
+
+	// value: QUANTITY - value transferred in Wei. Not set for static and delegate calls.
+	Value RawQuantityResponse `json:"value"`
+
+	// gas: QUANTITY - gas provided to the call.
+	Gas RawQuantityResponse `json:"gas"`
+
+	// gasUsed: QUANTITY - gas used by the call, including its internal calls.
+	GasUsed RawQuantityResponse `json:"gasUsed"`
+
+	// input: DATA - the call data, or the init code for creations.
+	Input RawDataResponse `json:"input"`
+
+	// output: DATA - the return data, or the revert data when the call failed.
+	Output RawDataResponse `json:"output"`
+
+	// error: the error message when the call failed.
+	Error string `json:"error,omitempty"`
+
+	// revertReason: the decoded revert reason, when the tracer could decode one.
+	RevertReason string `json:"revertReason,omitempty"`
+
+	// calls: the internal calls made by this call.
+	Calls []CallFrame `json:"calls,omitempty"`
+}
+
+// DecodeCallFrame decodes the result of debug_traceTransaction called with the
+// callTracer.
+func DecodeCallFrame(data json.RawMessage) (*CallFrame, error) {
+	frame := new(CallFrame)
+	if err := json.Unmarshal(data, frame); err != nil {
+		return nil, fmt.Errorf("unable to decode call frame: %w", err)
+	}
+	return frame, nil
+}
+
+// Flatten returns the frame and all of its internal calls in the order they
+// were made, i.e. a depth first pre-order walk of the tree.
+func (f *CallFrame) Flatten() []*CallFrame {
+	frames := []*CallFrame{f}
+	for idx := range f.Calls {
+		frames = append(frames, f.Calls[idx].Flatten()...)
+	}
+	return frames
+}
+
+// Failed reports whether the call reverted or otherwise errored.
+func (f *CallFrame) Failed() bool {
+	return f.Error != ""
+}
+
+// CreatedContract is a contract deployed during a transaction's execution.
+type CreatedContract struct {
+	Address ethcommon.Address
+	Creator ethcommon.Address
+	// Opcode is either CREATE or CREATE2.
+	Opcode string
+}
+
+// CreatedContracts returns the contracts deployed by the call and its internal
+// calls, in the order they were created. This includes contracts deployed by
+// factories, which only show up in traces, as well as the transaction itself
+// if it's a contract creation. Creations that failed, or were reverted along
+// with a failed parent call, didn't deploy anything and are left out.
+func (f *CallFrame) CreatedContracts() []CreatedContract {
+	created := make([]CreatedContract, 0)
+	if f.Failed() {
+		return created
+	}
+	if f.Type == "CREATE" || f.Type == "CREATE2" {
+		created = append(created, CreatedContract{
+			Address: f.To.ToAddress(),
+			Creator: f.From.ToAddress(),
+			Opcode:  f.Type,
+		})
+	}
+	for idx := range f.Calls {
+		created = append(created, f.Calls[idx].CreatedContracts()...)
+	}
+	return created
+}
+
+// Reason returns why the call failed, decoded from its output with
+// DecodeRevertReason, or the tracer's error message when the output doesn't
+// carry a reason. It returns an empty string for calls that succeeded.
+func (f *CallFrame) Reason() string {
+	if !f.Failed() {
+		return ""
+	}
+	if reason := DecodeRevertReason(f.Output.ToBytes()); reason != "" {
+		return reason
+	}
+	return f.Error
+}
+
+// DecodeRevertReason decodes the revert data returned by a failed call. An
+// Error(string) revert returns the string and a Panic(uint256) revert returns
+// a description of the panic code prefixed with "panic: ". Custom errors can't
+// be decoded without their ABI, so their 4 byte selector is returned as hex.
+// Empty data, and Error or Panic reverts that can't be unpacked, e.g. because
+// they were truncated, return an empty string.
+func DecodeRevertReason(data []byte) string {
+	if len(data) < 4 {
+		return ""
+	}
+	reason, err := abi.UnpackRevert(data)
+	if err != nil {
+		if bytes.Equal(data[:4], errorSelector) || bytes.Equal(data[:4], panicSelector) {
+			return ""
+		}
+		return hexutil.Encode(data[:4])
+	}
+	if bytes.Equal(data[:4], panicSelector) {
+		return "panic: " + reason
+	}
+	return reason
+}
@@ -0,0 +1,188 @@
+package rpctypes
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const sampleCallTrace = `{
+	"type": "CALL",
+	"from": "0x00000000000000000000000000000000000000aa",
+	"to": "0x00000000000000000000000000000000000000bb",
+	"value": "0x0",
+	"gas": "0x7a120",
+	"gasUsed": "0x1d4c0",
+	"input": "0xa9059cbb",
+	"output": "0x",
+	"calls": [
+		{
+			"type": "DELEGATECALL",
+			"from": "0x00000000000000000000000000000000000000bb",
+			"to": "0x00000000000000000000000000000000000000cc",
+			"gas": "0x61a80",
+			"gasUsed": "0x2710",
+			"input": "0x",
+			"calls": [
+				{
+					"type": "CALL",
+					"from": "0x00000000000000000000000000000000000000bb",
+					"to": "0x00000000000000000000000000000000000000dd",
+					"value": "0xde0b6b3a7640000",
+					"gas": "0x8fc",
+					"gasUsed": "0x0",
+					"input": "0x"
+				}
+			]
+		},
+		{
+			"type": "STATICCALL",
+			"from": "0x00000000000000000000000000000000000000bb",
+			"to": "0x00000000000000000000000000000000000000ee",
+			"gas": "0x2710",
+			"gasUsed": "0x2710",
+			"input": "0x",
+			"error": "out of gas"
+		}
+	]
+}`
+
+func TestDecodeCallFrame(t *testing.T) {
+	frame, err := DecodeCallFrame([]byte(sampleCallTrace))
+	if err != nil {
+		t.Fatalf("could not decode trace: %v", err)
+	}
+
+	if frame.Type != "CALL" || frame.GasUsed.ToUint64() != 120000 || len(frame.Calls) != 2 {
+		t.Errorf("unexpected top level frame %+v", frame)
+	}
+
+	flat := frame.Flatten()
+	expected := []string{"CALL", "DELEGATECALL", "CALL", "STATICCALL"}
+	if len(flat) != len(expected) {
+		t.Fatalf("expected %d frames, got %d", len(expected), len(flat))
+	}
+	for idx, typ := range expected {
+		if flat[idx].Type != typ {
+			t.Errorf("frame %d: expected type %s, got %s", idx, typ, flat[idx].Type)
+		}
+	}
+
+	if flat[2].Value.ToBigInt().String() != "1000000000000000000" {
+		t.Errorf("expected internal transfer of 1 ether, got %s", flat[2].Value.ToBigInt())
+	}
+	if !flat[3].Failed() || flat[0].Failed() {
+		t.Errorf("expected only the static call to fail")
+	}
+}
+
+func packRevert(t *testing.T, selector []byte, typ string, value any) []byte {
+	t.Helper()
+	abiType, err := abi.NewType(typ, "", nil)
+	if err != nil {
+		t.Fatalf("could not create type %s: %v", typ, err)
+	}
+	packed, err := abi.Arguments{{Type: abiType}}.Pack(value)
+	if err != nil {
+		t.Fatalf("could not pack %v: %v", value, err)
+	}
+	return append(append([]byte{}, selector...), packed...)
+}
+
+func TestDecodeRevertReason(t *testing.T) {
+	type test struct {
+		name   string
+		data   []byte
+		reason string
+	}
+
+	tests := []test{
+		{name: "string", data: packRevert(t, errorSelector, "string", "insufficient balance"), reason: "insufficient balance"},
+		{name: "arithmetic panic", data: packRevert(t, panicSelector, "uint256", big.NewInt(0x11)), reason: "panic: arithmetic underflow or overflow"},
+		{name: "unknown panic", data: packRevert(t, panicSelector, "uint256", big.NewInt(0x99)), reason: "panic: unknown panic code: 0x99"},
+		{name: "custom error", data: []byte{0xde, 0xad, 0xbe, 0xef, 0x01}, reason: "0xdeadbeef"},
+		{name: "empty", data: nil, reason: ""},
+		{name: "truncated string", data: packRevert(t, errorSelector, "string", "insufficient balance")[:36], reason: ""},
+		{name: "selector only string", data: errorSelector, reason: ""},
+		{name: "truncated panic", data: packRevert(t, panicSelector, "uint256", big.NewInt(0x11))[:20], reason: ""},
+	}
+
+	for _, test := range tests {
+		if reason := DecodeRevertReason(test.data); reason != test.reason {
+			t.Errorf("%s: expected reason %q, got %q", test.name, test.reason, reason)
+		}
+	}
+
+	frame := &CallFrame{Error: "execution reverted", Output: RawDataResponse(hexutil.Encode(tests[0].data))}
+	if reason := frame.Reason(); reason != "insufficient balance" {
+		t.Errorf("expected frame reason %q, got %q", "insufficient balance", reason)
+	}
+	frame = &CallFrame{Error: "out of gas", Output: "0x"}
+	if reason := frame.Reason(); reason != "out of gas" {
+		t.Errorf("expected frame reason %q, got %q", "out of gas", reason)
+	}
+}
+
+func TestCreatedContracts(t *testing.T) {
+	// A call to a factory that deploys a contract with CREATE2, which in turn
+	// deploys a helper with CREATE. A second deployment is reverted along with
+	// its parent call.
+	trace := `{
+		"type": "CALL",
+		"from": "0x00000000000000000000000000000000000000aa",
+		"to": "0x00000000000000000000000000000000000000fa",
+		"input": "0x",
+		"calls": [
+			{
+				"type": "CREATE2",
+				"from": "0x00000000000000000000000000000000000000fa",
+				"to": "0x00000000000000000000000000000000000000c2",
+				"input": "0x60",
+				"calls": [
+					{
+						"type": "CREATE",
+						"from": "0x00000000000000000000000000000000000000c2",
+						"to": "0x00000000000000000000000000000000000000c1",
+						"input": "0x60"
+					}
+				]
+			},
+			{
+				"type": "CALL",
+				"from": "0x00000000000000000000000000000000000000fa",
+				"to": "0x00000000000000000000000000000000000000bb",
+				"input": "0x",
+				"error": "execution reverted",
+				"calls": [
+					{
+						"type": "CREATE",
+						"from": "0x00000000000000000000000000000000000000bb",
+						"to": "0x00000000000000000000000000000000000000c3",
+						"input": "0x60"
+					}
+				]
+			}
+		]
+	}`
+	frame, err := DecodeCallFrame([]byte(trace))
+	if err != nil {
+		t.Fatalf("could not decode trace: %v", err)
+	}
+
+	created := frame.CreatedContracts()
+	expected := []CreatedContract{
+		{Address: ethcommon.HexToAddress("0xc2"), Creator: ethcommon.HexToAddress("0xfa"), Opcode: "CREATE2"},
+		{Address: ethcommon.HexToAddress("0xc1"), Creator: ethcommon.HexToAddress("0xc2"), Opcode: "CREATE"},
+	}
+	if len(created) != len(expected) {
+		t.Fatalf("expected %d created contracts, got %d: %+v", len(expected), len(created), created)
+	}
+	for idx := range expected {
+		if created[idx] != expected[idx] {
+			t.Errorf("contract %d: expected %+v, got %+v", idx, expected[idx], created[idx])
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package rpctypes
+
+import (
+	"fmt"
+	"strings"
+
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// TxType returns the EIP-2718 type of the transaction, which can be compared
+// with go-ethereum's type constants such as ethtypes.DynamicFeeTxType.
+func (i *implPolyTransaction) TxType() uint8 {
+	return uint8(i.Type())
+}
+
+// Validate checks that the fields required by the transaction's declared type
+// are present, e.g. the fee caps of a dynamic fee transaction, and returns an
+// error listing the missing ones. Types it doesn't know are an error too.
+func (i *implPolyTransaction) Validate() error {
+	var missing []string
+	require := func(present bool, field string) {
+		if !present {
+			missing = append(missing, field)
+		}
+	}
+
+	tx := i.inner
+	switch i.TxType() {
+	case ethtypes.LegacyTxType:
+		require(!tx.GasPrice.IsEmpty(), "gasPrice")
+	case ethtypes.AccessListTxType:
+		require(!tx.ChainID.IsEmpty(), "chainId")
+		require(!tx.GasPrice.IsEmpty(), "gasPrice")
+		require(tx.AccessList != nil, "accessList")
+	case ethtypes.DynamicFeeTxType:
+		require(!tx.ChainID.IsEmpty(), "chainId")
+		require(!tx.MaxFeePerGas.IsEmpty(), "maxFeePerGas")
+		require(!tx.MaxPriorityFeePerGas.IsEmpty(), "maxPriorityFeePerGas")
+		require(tx.AccessList != nil, "accessList")
+	case ethtypes.BlobTxType:
+		require(!tx.ChainID.IsEmpty(), "chainId")
+		require(!tx.MaxFeePerGas.IsEmpty(), "maxFeePerGas")
+		require(!tx.MaxPriorityFeePerGas.IsEmpty(), "maxPriorityFeePerGas")
+		require(tx.AccessList != nil, "accessList")
+		require(!tx.MaxFeePerBlobGas.IsEmpty(), "maxFeePerBlobGas")
+		require(len(tx.BlobVersionedHashes) > 0, "blobVersionedHashes")
+		// Blob transactions can't create contracts.
+		require(!tx.To.IsEmpty(), "to")
+	default:
+		return fmt.Errorf("transaction %s has unknown type %d", i.Hash(), i.Type())
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("type %d transaction %s is missing %s", i.TxType(), i.Hash(), strings.Join(missing, ", "))
+	}
+	return nil
+}
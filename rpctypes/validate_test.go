@@ -0,0 +1,68 @@
+package rpctypes
+
+import (
+	"strings"
+	"testing"
+
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestValidate(t *testing.T) {
+	type test struct {
+		name    string
+		tx      RawTransactionResponse
+		missing []string
+	}
+
+	hashes := []RawData32Response{"0x01000000000000000000000000000000000000000000000000000000000000aa"}
+	tests := []test{
+		{name: "legacy", tx: RawTransactionResponse{Type: "0x0", GasPrice: "0x1"}},
+		{name: "legacy without gas price", tx: RawTransactionResponse{Type: "0x0"}, missing: []string{"gasPrice"}},
+		{name: "access list", tx: RawTransactionResponse{Type: "0x1", ChainID: "0x89", GasPrice: "0x1", AccessList: []RawAccessTuple{}}},
+		{name: "access list without list", tx: RawTransactionResponse{Type: "0x1", ChainID: "0x89", GasPrice: "0x1"}, missing: []string{"accessList"}},
+		{
+			name: "dynamic fee",
+			tx:   RawTransactionResponse{Type: "0x2", ChainID: "0x89", MaxFeePerGas: "0x2", MaxPriorityFeePerGas: "0x1", AccessList: []RawAccessTuple{}},
+		},
+		{
+			name:    "dynamic fee with only a gas price",
+			tx:      RawTransactionResponse{Type: "0x2", ChainID: "0x89", GasPrice: "0x1", AccessList: []RawAccessTuple{}},
+			missing: []string{"maxFeePerGas", "maxPriorityFeePerGas"},
+		},
+		{
+			name: "blob",
+			tx: RawTransactionResponse{Type: "0x3", ChainID: "0x1", MaxFeePerGas: "0x2", MaxPriorityFeePerGas: "0x1", AccessList: []RawAccessTuple{},
+				MaxFeePerBlobGas: "0x1", BlobVersionedHashes: hashes, To: "0x00000000000000000000000000000000000000aa"},
+		},
+		{
+			name:    "blob without blobs",
+			tx:      RawTransactionResponse{Type: "0x3", ChainID: "0x1", MaxFeePerGas: "0x2", MaxPriorityFeePerGas: "0x1", AccessList: []RawAccessTuple{}},
+			missing: []string{"maxFeePerBlobGas", "blobVersionedHashes", "to"},
+		},
+		{name: "unknown type", tx: RawTransactionResponse{Type: "0x7f"}, missing: []string{"unknown type 127"}},
+	}
+
+	for _, tc := range tests {
+		tx := NewPolyTransaction(&tc.tx)
+		err := tx.Validate()
+		if len(tc.missing) == 0 {
+			if err != nil {
+				t.Errorf("%s: expected no error, got %v", tc.name, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s: expected an error for %v", tc.name, tc.missing)
+			continue
+		}
+		for _, field := range tc.missing {
+			if !strings.Contains(err.Error(), field) {
+				t.Errorf("%s: expected the error to mention %s, got %v", tc.name, field, err)
+			}
+		}
+	}
+
+	if tx := NewPolyTransaction(&RawTransactionResponse{Type: "0x2"}); tx.TxType() != ethtypes.DynamicFeeTxType {
+		t.Errorf("expected type %d, got %d", ethtypes.DynamicFeeTxType, tx.TxType())
+	}
+}
@@ -0,0 +1,31 @@
+package rpctypes
+
+import (
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// WithdrawalsRoot returns the root of the block's withdrawals trie. Blocks
+// from before Shanghai return the zero hash.
+func (i *implPolyBlock) WithdrawalsRoot() ethcommon.Hash {
+	return i.inner.WithdrawalsRoot.ToHash()
+}
+
+// Withdrawals decodes the validator withdrawals processed by the block. The
+// amounts are in Gwei. Blocks from before Shanghai return nil.
+func (i *implPolyBlock) Withdrawals() ethtypes.Withdrawals {
+	if len(i.inner.Withdrawals) == 0 {
+		return nil
+	}
+
+	withdrawals := make(ethtypes.Withdrawals, 0, len(i.inner.Withdrawals))
+	for _, w := range i.inner.Withdrawals {
+		withdrawals = append(withdrawals, &ethtypes.Withdrawal{
+			Index:     w.Index.ToUint64(),
+			Validator: w.ValidatorIndex.ToUint64(),
+			Address:   w.Address.ToAddress(),
+			Amount:    w.Amount.ToUint64(),
+		})
+	}
+	return withdrawals
+}
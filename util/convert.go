@@ -3,20 +3,20 @@ package util
 import (
 	"encoding/hex"
 	"math/big"
-	"strings"
 
 	"github.com/rs/zerolog/log"
+
+	"github.com/maticnetwork/polygon-cli/rpctypes"
 )
 
 // HexToBigInt converts a hexadecimal string to a big integer.
 func HexToBigInt(hexString string) (*big.Int, error) {
-	// Clean up the string.
-	// - Remove the `0x` prefix.
-	// - If the length of the string is odd, pad it with a leading zero.
-	hexString = strings.TrimPrefix(hexString, "0x")
-	if len(hexString)%2 != 0 {
-		log.Trace().Str("original", hexString).Msg("Hexadecimal string of odd length padded with a leading zero")
-		hexString = "0" + hexString
+	// Clean up the string: trim whitespace, remove the `0x` prefix, lowercase
+	// and pad odd lengths with a leading zero.
+	hexString, err := rpctypes.ParseHexString(hexString)
+	if err != nil {
+		log.Error().Err(err).Msg("Unable to parse hex string")
+		return nil, err
 	}
 
 	// Decode the hexadecimal string into a byte slice and return the `big.Int` value.
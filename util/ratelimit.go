@@ -0,0 +1,58 @@
+package util
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/time/rate"
+)
+
+// rateLimitedTransport is an http.RoundTripper that waits for a token from the
+// limiter before sending each request.
+type rateLimitedTransport struct {
+	limiter *rate.Limiter
+	base    http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
+
+// NewRateLimitedTransport wraps the base transport, or the default one if it's
+// nil, so at most rps requests are sent per second. The token bucket has a
+// burst of one, so bursts from concurrent callers are smoothed out rather than
+// sent at once.
+func NewRateLimitedTransport(rps float64, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &rateLimitedTransport{
+		limiter: rate.NewLimiter(rate.Limit(rps), 1),
+		base:    base,
+	}
+}
+
+// DialRateLimited dials the RPC endpoint with the outgoing requests limited to
+// rps requests per second, which keeps requests under a provider's cap before
+// it starts responding with 429s. A batch is a single request. A limit of zero
+// or less is unlimited. The limit only applies to HTTP endpoints, since
+// websocket and IPC connections aren't made of separate requests.
+func DialRateLimited(ctx context.Context, rawurl string, rps float64) (*ethrpc.Client, error) {
+	if rps <= 0 {
+		return ethrpc.DialContext(ctx, rawurl)
+	}
+
+	if u, err := url.Parse(rawurl); err == nil && u.Scheme != "http" && u.Scheme != "https" {
+		log.Warn().Str("url", rawurl).Msg("The rate limit only applies to HTTP endpoints")
+	}
+
+	client := &http.Client{Transport: NewRateLimitedTransport(rps, nil)}
+	return ethrpc.DialOptions(ctx, rawurl, ethrpc.WithHTTPClient(client))
+}
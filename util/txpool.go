@@ -0,0 +1,81 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+// methodNotFoundCode is the JSON-RPC error code for a method the node doesn't
+// serve.
+const methodNotFoundCode = -32601
+
+// ErrTxPoolUnsupported is returned when the node doesn't serve the txpool
+// namespace.
+var ErrTxPoolUnsupported = errors.New("node doesn't support txpool_status")
+
+// TxPoolSample is the transaction pool occupancy at a point in time.
+type TxPoolSample struct {
+	Time    time.Time `json:"time"`
+	Pending uint64    `json:"pending"`
+	Queued  uint64    `json:"queued"`
+}
+
+// GetTxPoolStatus returns the number of pending and queued transactions in the
+// node's transaction pool.
+func GetTxPoolStatus(ctx context.Context, c *ethrpc.Client) (uint64, uint64, error) {
+	result, err := Call(ctx, c, "txpool_status")
+	if err != nil {
+		var rpcErr ethrpc.Error
+		if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == methodNotFoundCode {
+			return 0, 0, ErrTxPoolUnsupported
+		}
+		return 0, 0, err
+	}
+
+	status := new(txpoolStatus)
+	if err = json.Unmarshal(result, status); err != nil {
+		return 0, 0, fmt.Errorf("unable to decode txpool status: %w", err)
+	}
+	pending, err := tryCastToUint64(status.Pending)
+	if err != nil {
+		return 0, 0, err
+	}
+	queued, err := tryCastToUint64(status.Queued)
+	if err != nil {
+		return 0, 0, err
+	}
+	return pending, queued, nil
+}
+
+// SampleTxPool samples the transaction pool occupancy every interval until it
+// has the given number of samples, returning them as a time series. If the
+// context is done first, the samples taken so far are returned with the
+// context's error. Nodes without the txpool namespace return
+// ErrTxPoolUnsupported.
+func SampleTxPool(ctx context.Context, c *ethrpc.Client, interval time.Duration, samples int) ([]TxPoolSample, error) {
+	series := make([]TxPoolSample, 0, samples)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		pending, queued, err := GetTxPoolStatus(ctx, c)
+		if err != nil {
+			return series, err
+		}
+		series = append(series, TxPoolSample{Time: time.Now(), Pending: pending, Queued: queued})
+		if len(series) >= samples {
+			return series, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return series, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -3,15 +3,21 @@ package util
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/big"
+	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/cenkalti/backoff"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/ethclient"
 	ethrpc "github.com/ethereum/go-ethereum/rpc"
 	"github.com/rs/zerolog/log"
+
+	"github.com/maticnetwork/polygon-cli/rpctypes"
 )
 
 type (
@@ -80,6 +86,36 @@ func GetBlockRangeInPages(ctx context.Context, from, to, pageSize uint64, c *eth
 	return allBlocks, nil
 }
 
+// ChunkRange splits the inclusive range [from, to] into chunks contiguous
+// sub-ranges, also inclusive, for fetching in parallel. The chunk sizes differ
+// by at most one, with the larger chunks first. A range with fewer blocks than
+// chunks is split into single block chunks, and an empty range or a chunk count
+// below one returns no chunks.
+func ChunkRange(from, to *big.Int, chunks int) [][2]*big.Int {
+	if chunks < 1 || from.Cmp(to) > 0 {
+		return nil
+	}
+
+	total := new(big.Int).Sub(to, from)
+	total.Add(total, big.NewInt(1))
+	if total.Cmp(big.NewInt(int64(chunks))) < 0 {
+		chunks = int(total.Int64())
+	}
+
+	size, rem := new(big.Int).QuoRem(total, big.NewInt(int64(chunks)), new(big.Int))
+	ranges := make([][2]*big.Int, 0, chunks)
+	start := new(big.Int).Set(from)
+	for i := 0; i < chunks; i++ {
+		end := new(big.Int).Add(start, size)
+		if int64(i) >= rem.Int64() {
+			end.Sub(end, big.NewInt(1))
+		}
+		ranges = append(ranges, [2]*big.Int{start, end})
+		start = new(big.Int).Add(end, big.NewInt(1))
+	}
+	return ranges
+}
+
 func GetReceipts(ctx context.Context, rawBlocks []*json.RawMessage, c *ethrpc.Client, batchSize uint64) ([]*json.RawMessage, error) {
 	txHashes := make([]string, 0)
 	txHashMap := make(map[string]string, 0)
@@ -165,6 +201,97 @@ func GetReceipts(ctx context.Context, rawBlocks []*json.RawMessage, c *ethrpc.Cl
 	return receipts, nil
 }
 
+// callRetries is how many times Call retries a transient transport error.
+const callRetries = 4
+
+// Call invokes an arbitrary JSON-RPC method, such as debug_traceTransaction or
+// trace_block, that doesn't have a typed wrapper. The client handles the
+// request envelope and transport. Transient transport errors, such as dropped
+// connections or HTTP 429 and 5xx responses, are retried with an exponential
+// backoff, while JSON-RPC errors are returned right away with their code and
+// data. The raw result is returned as is and the caller owns decoding it.
+func Call(ctx context.Context, c *ethrpc.Client, method string, params ...any) (json.RawMessage, error) {
+	var result json.RawMessage
+	retryable := func() error {
+		err := c.CallContext(ctx, &result, method, params...)
+		if err != nil && !transientCallError(err) {
+			return backoff.Permanent(err)
+		}
+		return err
+	}
+	b := backoff.WithContext(backoff.WithMaxRetries(backoff.NewExponentialBackOff(), callRetries), ctx)
+	err := backoff.Retry(retryable, b)
+	if err == nil {
+		return result, nil
+	}
+
+	var rpcErr ethrpc.Error
+	if !errors.As(err, &rpcErr) {
+		return nil, fmt.Errorf("%s call failed: %w", method, err)
+	}
+	var dataErr ethrpc.DataError
+	if errors.As(err, &dataErr) && dataErr.ErrorData() != nil {
+		return nil, fmt.Errorf("%s call failed with code %d and data %v: %w", method, rpcErr.ErrorCode(), dataErr.ErrorData(), err)
+	}
+	return nil, fmt.Errorf("%s call failed with code %d: %w", method, rpcErr.ErrorCode(), err)
+}
+
+// transientCallError reports whether the call failed in the transport in a way
+// that may not happen again, rather than with an error from the node or the
+// caller giving up.
+func transientCallError(err error) bool {
+	var rpcErr ethrpc.Error
+	if errors.As(err, &rpcErr) {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var httpErr ethrpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode >= http.StatusInternalServerError
+	}
+	return true
+}
+
+// TraceTransaction traces the transaction with the callTracer and decodes the
+// resulting call frame tree.
+func TraceTransaction(ctx context.Context, c *ethrpc.Client, txHash string) (*rpctypes.CallFrame, error) {
+	result, err := Call(ctx, c, "debug_traceTransaction", txHash, map[string]any{"tracer": "callTracer"})
+	if err != nil {
+		return nil, err
+	}
+	return rpctypes.DecodeCallFrame(result)
+}
+
+// ErrBlockNotFound is returned when the node doesn't know the requested block.
+// It's rpctypes.ErrBlockNotFound, so either can be checked with errors.Is.
+var ErrBlockNotFound = rpctypes.ErrBlockNotFound
+
+// GetBlockByNumber fetches the block with eth_getBlockByNumber. When fullTx is
+// false the block only has transaction hashes, see PolyBlock.TransactionHashes.
+func GetBlockByNumber(ctx context.Context, c *ethrpc.Client, number *big.Int, fullTx bool) (rpctypes.PolyBlock, error) {
+	return getBlock(ctx, c, "eth_getBlockByNumber", hexutil.EncodeBig(number), fullTx)
+}
+
+// GetBlockByHash fetches the block with eth_getBlockByHash. When fullTx is
+// false the block only has transaction hashes, see PolyBlock.TransactionHashes.
+func GetBlockByHash(ctx context.Context, c *ethrpc.Client, hash ethcommon.Hash, fullTx bool) (rpctypes.PolyBlock, error) {
+	return getBlock(ctx, c, "eth_getBlockByHash", hash.Hex(), fullTx)
+}
+
+func getBlock(ctx context.Context, c *ethrpc.Client, method string, id string, fullTx bool) (rpctypes.PolyBlock, error) {
+	result, err := Call(ctx, c, method, id, fullTx)
+	if err != nil {
+		return nil, err
+	}
+	block, err := rpctypes.NewPolyBlockFromJSON(result, fullTx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get block %s: %w", id, err)
+	}
+	return block, nil
+}
+
 func GetTxPoolSize(rpc *ethrpc.Client) (uint64, error) {
 	var status = new(txpoolStatus)
 	err := rpc.Call(status, "txpool_status")
@@ -195,9 +322,9 @@ func tryCastToUint64(val any) (uint64, error) {
 	}
 }
 func convHexToUint64(hexString string) (uint64, error) {
-	hexString = strings.TrimPrefix(hexString, "0x")
-	if len(hexString)%2 != 0 {
-		hexString = "0" + hexString
+	hexString, err := rpctypes.ParseHexString(hexString)
+	if err != nil {
+		return 0, err
 	}
 
 	result, err := strconv.ParseUint(hexString, 16, 64)
@@ -0,0 +1,279 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/maticnetwork/polygon-cli/rpctypes"
+)
+
+var testBlockHash = ethcommon.HexToHash("0xabc")
+
+// testEthService is a mock of the eth namespace that knows a single block.
+type testEthService struct{}
+
+func (s *testEthService) block(fullTx bool) map[string]any {
+	tx := any(ethcommon.HexToHash("0x01").Hex())
+	if fullTx {
+		tx = map[string]any{"hash": ethcommon.HexToHash("0x01").Hex()}
+	}
+	return map[string]any{
+		"number":       "0xa",
+		"hash":         testBlockHash.Hex(),
+		"transactions": []any{tx},
+	}
+}
+
+func (s *testEthService) GetBlockByHash(hash ethcommon.Hash, fullTx bool) map[string]any {
+	if hash != testBlockHash {
+		return nil
+	}
+	return s.block(fullTx)
+}
+
+func (s *testEthService) GetBlockByNumber(number hexutil.Big, fullTx bool) map[string]any {
+	if number.ToInt().Int64() != 10 {
+		return nil
+	}
+	return s.block(fullTx)
+}
+
+// testTxPoolService is a mock of the txpool namespace where the pool grows by
+// one pending transaction every call.
+type testTxPoolService struct {
+	calls uint64
+}
+
+func (s *testTxPoolService) Status() map[string]hexutil.Uint64 {
+	s.calls++
+	return map[string]hexutil.Uint64{"pending": hexutil.Uint64(s.calls), "queued": 2}
+}
+
+func newTestClient(t *testing.T, services map[string]any) *ethrpc.Client {
+	t.Helper()
+	server := ethrpc.NewServer()
+	for name, service := range services {
+		if err := server.RegisterName(name, service); err != nil {
+			t.Fatalf("could not register service: %v", err)
+		}
+	}
+	t.Cleanup(server.Stop)
+	return ethrpc.DialInProc(server)
+}
+
+func TestGetBlock(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, map[string]any{"eth": new(testEthService)})
+
+	block, err := GetBlockByHash(ctx, c, testBlockHash, true)
+	if err != nil {
+		t.Fatalf("could not get block by hash: %v", err)
+	}
+	if block.Hash() != testBlockHash || len(block.Transactions()) != 1 {
+		t.Errorf("unexpected block %s", block)
+	}
+
+	block, err = GetBlockByNumber(ctx, c, big.NewInt(10), false)
+	if err != nil {
+		t.Fatalf("could not get block by number: %v", err)
+	}
+	if block.Number().Int64() != 10 || len(block.TransactionHashes()) != 1 {
+		t.Errorf("unexpected block %s", block)
+	}
+
+	if _, err = GetBlockByHash(ctx, c, ethcommon.HexToHash("0xdef"), true); !errors.Is(err, ErrBlockNotFound) || !errors.Is(err, rpctypes.ErrBlockNotFound) {
+		t.Errorf("expected a not found error for an unknown hash, got %v", err)
+	}
+}
+
+func TestSampleTxPool(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, map[string]any{"txpool": new(testTxPoolService)})
+
+	samples, err := SampleTxPool(ctx, c, time.Millisecond, 3)
+	if err != nil {
+		t.Fatalf("could not sample txpool: %v", err)
+	}
+	if len(samples) != 3 {
+		t.Fatalf("expected 3 samples, got %d", len(samples))
+	}
+	for idx, s := range samples {
+		if s.Pending != uint64(idx+1) || s.Queued != 2 {
+			t.Errorf("unexpected sample %d: %+v", idx, s)
+		}
+		if idx > 0 && s.Time.Before(samples[idx-1].Time) {
+			t.Errorf("expected samples in time order")
+		}
+	}
+
+	unsupported := newTestClient(t, map[string]any{"eth": new(testEthService)})
+	if _, err = SampleTxPool(ctx, unsupported, time.Millisecond, 3); !errors.Is(err, ErrTxPoolUnsupported) {
+		t.Errorf("expected an unsupported error, got %v", err)
+	}
+}
+
+func TestChunkRange(t *testing.T) {
+	type test struct {
+		name     string
+		from, to int64
+		chunks   int
+		expected [][2]int64
+	}
+
+	tests := []test{
+		{name: "even", from: 0, to: 9, chunks: 2, expected: [][2]int64{{0, 4}, {5, 9}}},
+		{name: "uneven", from: 10, to: 20, chunks: 3, expected: [][2]int64{{10, 13}, {14, 17}, {18, 20}}},
+		{name: "fewer blocks than chunks", from: 5, to: 6, chunks: 4, expected: [][2]int64{{5, 5}, {6, 6}}},
+		{name: "single block", from: 7, to: 7, chunks: 1, expected: [][2]int64{{7, 7}}},
+		{name: "empty range", from: 8, to: 7, chunks: 2, expected: nil},
+		{name: "no chunks", from: 0, to: 9, chunks: 0, expected: nil},
+	}
+
+	for _, tc := range tests {
+		ranges := ChunkRange(big.NewInt(tc.from), big.NewInt(tc.to), tc.chunks)
+		if len(ranges) != len(tc.expected) {
+			t.Errorf("%s: expected %d chunks, got %d", tc.name, len(tc.expected), len(ranges))
+			continue
+		}
+		for i, r := range ranges {
+			if r[0].Int64() != tc.expected[i][0] || r[1].Int64() != tc.expected[i][1] {
+				t.Errorf("%s: expected chunk %d to be %v, got [%s %s]", tc.name, i, tc.expected[i], r[0], r[1])
+			}
+		}
+	}
+}
+
+func TestDialRateLimited(t *testing.T) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":"0x1"}`, req.ID)
+	}))
+	defer server.Close()
+
+	const rps = 20
+	c, err := DialRateLimited(context.Background(), server.URL, rps)
+	if err != nil {
+		t.Fatalf("unable to dial: %v", err)
+	}
+	defer c.Close()
+
+	// Send the requests from concurrent callers, which would all be sent at
+	// once without the limit.
+	const calls = 10
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var result string
+			if err := c.Call(&result, "eth_blockNumber"); err != nil {
+				t.Errorf("call failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	if requests.Load() != calls {
+		t.Fatalf("expected %d requests, got %d", calls, requests.Load())
+	}
+	// The first request is sent right away and each one after it waits for a
+	// token, so the calls can't finish faster than the limit allows.
+	if minimum := time.Duration(calls-1) * time.Second / rps; elapsed < minimum {
+		t.Errorf("expected %d requests to take at least %s at %d requests per second, took %s", calls, minimum, rps, elapsed)
+	}
+}
+
+func TestCall(t *testing.T) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch req.Method {
+		case "test_ok":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":{"value":"0x1"}}`, req.ID)
+		case "test_revert":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"error":{"code":3,"message":"execution reverted","data":"0x08c379a0"}}`, req.ID)
+		case "test_flaky":
+			// Fail the first request of the method with a server error.
+			if n == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":"0x2"}`, req.ID)
+		case "test_bad_request":
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	c, err := ethrpc.DialContext(ctx, server.URL)
+	if err != nil {
+		t.Fatalf("unable to dial: %v", err)
+	}
+	defer c.Close()
+
+	type test struct {
+		method   string
+		result   string
+		err      string
+		requests int64
+	}
+	tests := []test{
+		{method: "test_ok", result: `{"value":"0x1"}`, requests: 1},
+		// JSON-RPC errors are never retried, and keep their code and data.
+		{method: "test_revert", err: "with code 3 and data 0x08c379a0", requests: 1},
+		// Transient transport errors are retried.
+		{method: "test_flaky", result: `"0x2"`, requests: 2},
+		// Other HTTP errors aren't transient.
+		{method: "test_bad_request", err: "400", requests: 1},
+	}
+
+	for _, tc := range tests {
+		requests.Store(0)
+		result, err := Call(ctx, c, tc.method)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("%s: expected an error containing %q, got %v", tc.method, tc.err, err)
+			}
+		} else if err != nil || string(result) != tc.result {
+			t.Errorf("%s: expected %s, got %s (%v)", tc.method, tc.result, result, err)
+		}
+		if requests.Load() != tc.requests {
+			t.Errorf("%s: expected %d requests, got %d", tc.method, tc.requests, requests.Load())
+		}
+	}
+}